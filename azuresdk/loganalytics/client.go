@@ -0,0 +1,315 @@
+package loganalytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/remeh/sizedwaitgroup"
+
+	"github.com/webdevops/go-common/azuresdk/armclient"
+	"github.com/webdevops/go-common/azuresdk/cloudconfig"
+	"github.com/webdevops/go-common/prometheus/kusto"
+)
+
+const (
+	DefaultConcurrency = 5
+
+	moduleName    = "loganalytics"
+	moduleVersion = "v1"
+)
+
+type (
+	// Client executes kusto queries against Log Analytics workspaces (or Azure ResourceGraph)
+	Client struct {
+		armClient *armclient.ArmClient
+
+		// resourceGraphQuery executes ResourceGraph queries (ArmClient.ExecuteResourceGraphQuery)
+		resourceGraphQuery func(ctx context.Context, query string, options armclient.ResourceGraphOptions) ([]map[string]interface{}, error)
+
+		endpoint       string
+		audience       string
+		clientOptions  *azcore.ClientOptions
+		pipeline       runtime.Pipeline
+		concurrency    int
+		workspaceField string
+	}
+
+	// QueryResult contains the rows of all workspaces and the errors of failed (or partially failed) workspaces
+	QueryResult struct {
+		Rows   []map[string]interface{}
+		Errors []*WorkspaceError
+	}
+
+	// WorkspaceError is the error of one workspace, partial errors still contain rows
+	WorkspaceError struct {
+		Workspace string
+		Partial   bool
+		Err       error
+	}
+
+	// QueryError is the error object which is returned by the Log Analytics API
+	QueryError struct {
+		Code       string        `json:"code"`
+		Message    string        `json:"message"`
+		InnerError *QueryError   `json:"innererror"`
+		Details    []*QueryError `json:"details"`
+	}
+
+	queryRequest struct {
+		Query    string `json:"query"`
+		Timespan string `json:"timespan,omitempty"`
+	}
+
+	queryResponse struct {
+		kusto.QueryResponse
+		Error *QueryError `json:"error"`
+	}
+)
+
+// NewClient creates a new Log Analytics query client using the credentials and cloud configuration of the ArmClient
+func NewClient(armClient *armclient.ArmClient, opts ...ClientOptionFunc) (*Client, error) {
+	client := &Client{
+		armClient:          armClient,
+		resourceGraphQuery: armClient.ExecuteResourceGraphQuery,
+		clientOptions:      armClient.NewAzCoreClientOptions(),
+		concurrency:        DefaultConcurrency,
+	}
+
+	if serviceConfig, exists := armClient.GetCloudConfig().Services[cloudconfig.ServiceNameLogAnalyticsWorkspace]; exists {
+		client.endpoint = serviceConfig.Endpoint
+		client.audience = serviceConfig.Audience
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	if client.endpoint == "" || client.audience == "" {
+		return nil, fmt.Errorf(`no service configuration "%v" found for Azure cloud "%v"`, cloudconfig.ServiceNameLogAnalyticsWorkspace, armClient.GetCloudName())
+	}
+
+	scope := strings.TrimSuffix(client.audience, "/") + "/.default"
+	pipeline := runtime.NewPipeline(
+		moduleName,
+		moduleVersion,
+		runtime.PipelineOptions{
+			PerRetry: []policy.Policy{
+				runtime.NewBearerTokenPolicy(armClient.GetCred(), []string{scope}, nil),
+			},
+		},
+		client.clientOptions,
+	)
+	client.pipeline = pipeline
+
+	return client, nil
+}
+
+// ExecuteQuery executes the query depending on the query mode
+//
+//	workspace: the query is executed on all workspaces of the query (in parallel)
+//	resourcegraph: the query is executed via Azure ResourceGraph on the subscriptions
+//	and management groups of the query (subscriptions of the ArmClient if both are not set)
+//
+//	parameters of the query are rendered into the query (see kusto.Query.RenderQuery)
+//
+//	rows can be processed with kusto.NewQueryPlan, workspaces which failed
+//	are reported in QueryResult.Errors; an error is only returned if the query is
+//	not valid or if all workspaces failed
+func (c *Client) ExecuteQuery(ctx context.Context, query kusto.Query) (*QueryResult, error) {
+	renderedQuery, err := query.RenderQuery()
+	if err != nil {
+		return nil, err
+	}
+	query.Query = renderedQuery
+	query.Parameters = nil
+
+	switch query.GetQueryMode() {
+	case kusto.QueryModeWorkspace:
+		return c.executeWorkspacesQuery(ctx, query)
+	case kusto.QueryModeResourceGraph:
+		return c.executeResourceGraphQuery(ctx, query)
+	default:
+		return nil, fmt.Errorf(`queryMode "%v" is not supported`, query.QueryMode)
+	}
+}
+
+// executeResourceGraphQuery executes the query via Azure ResourceGraph
+func (c *Client) executeResourceGraphQuery(ctx context.Context, query kusto.Query) (*QueryResult, error) {
+	options := armclient.ResourceGraphOptions{
+		Subscriptions:    query.GetSubscriptions(),
+		ManagementGroups: query.GetManagementGroups(),
+	}
+
+	if len(options.Subscriptions) == 0 && len(options.ManagementGroups) == 0 {
+		subscriptionList, err := c.armClient.ListSubscriptions(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for subscriptionId := range subscriptionList {
+			options.Subscriptions = append(options.Subscriptions, subscriptionId)
+		}
+		slices.Sort(options.Subscriptions)
+	}
+
+	rows, err := c.resourceGraphQuery(ctx, query.Query, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryResult{Rows: rows, Errors: []*WorkspaceError{}}, nil
+}
+
+// executeWorkspacesQuery executes the query on all workspaces of the query (in parallel)
+func (c *Client) executeWorkspacesQuery(ctx context.Context, query kusto.Query) (*QueryResult, error) {
+	workspaces := query.GetWorkspaces()
+	if len(workspaces) == 0 {
+		return nil, errors.New("no workspaces set for query")
+	}
+
+	workspaceRows := make([][]map[string]interface{}, len(workspaces))
+	workspaceErrors := make([]*WorkspaceError, len(workspaces))
+
+	wg := sizedwaitgroup.New(c.concurrency)
+	for i, workspace := range workspaces {
+		wg.Add()
+		go func(i int, workspace string) {
+			defer wg.Done()
+
+			rows, err := c.ExecuteWorkspaceQuery(ctx, workspace, query.Query, query.GetTimespan())
+			workspaceRows[i] = rows
+			if err != nil {
+				workspaceErr := &WorkspaceError{Workspace: workspace, Err: err}
+				var queryErr *QueryError
+				workspaceErr.Partial = errors.As(err, &queryErr)
+				workspaceErrors[i] = workspaceErr
+			}
+		}(i, workspace)
+	}
+	wg.Wait()
+
+	result := &QueryResult{
+		Rows:   []map[string]interface{}{},
+		Errors: []*WorkspaceError{},
+	}
+	for i := range workspaces {
+		result.Rows = append(result.Rows, workspaceRows[i]...)
+		if workspaceErrors[i] != nil {
+			result.Errors = append(result.Errors, workspaceErrors[i])
+		}
+	}
+
+	if len(result.Errors) == len(workspaces) && len(result.Rows) == 0 {
+		return result, result.Err()
+	}
+
+	return result, nil
+}
+
+// QueryExecutor returns ExecuteQuery as executor for kusto.NewProcessor, partial results are returned with the error
+func (c *Client) QueryExecutor() kusto.QueryExecutorFunc {
+	return func(ctx context.Context, query kusto.Query) ([]map[string]interface{}, error) {
+		result, err := c.ExecuteQuery(ctx, query)
+		if err != nil {
+			if result != nil {
+				return result.Rows, err
+			}
+			return nil, err
+		}
+		return result.Rows, result.Err()
+	}
+}
+
+// ExecuteWorkspaceQuery executes the query on one workspace
+//
+//	if the workspace returned a partial error the rows are returned together with a *QueryError
+func (c *Client) ExecuteWorkspaceQuery(ctx context.Context, workspace, query, timespan string) ([]map[string]interface{}, error) {
+	requestUrl := fmt.Sprintf("%s/v1/workspaces/%s/query", strings.TrimSuffix(c.endpoint, "/"), url.PathEscape(workspace))
+
+	req, err := runtime.NewRequest(ctx, http.MethodPost, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := runtime.MarshalAsJSON(req, queryRequest{Query: query, Timespan: timespan}); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.pipeline.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return nil, runtime.NewResponseError(resp)
+	}
+
+	result := queryResponse{}
+	if err := runtime.UnmarshalAsJSON(resp, &result); err != nil {
+		return nil, err
+	}
+
+	rows, err := result.GetRows()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.workspaceField != "" {
+		for _, row := range rows {
+			row[c.workspaceField] = workspace
+		}
+	}
+
+	if result.Error != nil {
+		return rows, result.Error
+	}
+
+	return rows, nil
+}
+
+// IsPartial returns true if at least one workspace failed but rows were returned
+func (r *QueryResult) IsPartial() bool {
+	return len(r.Errors) > 0 && len(r.Rows) > 0
+}
+
+// Err returns all workspace errors as one error (or nil if no workspace failed)
+func (r *QueryResult) Err() error {
+	errList := make([]error, 0, len(r.Errors))
+	for _, err := range r.Errors {
+		errList = append(errList, err)
+	}
+	return errors.Join(errList...)
+}
+
+func (e *WorkspaceError) Error() string {
+	if e.Partial {
+		return fmt.Sprintf(`workspace "%v": partial error: %v`, e.Workspace, e.Err)
+	}
+	return fmt.Sprintf(`workspace "%v": %v`, e.Workspace, e.Err)
+}
+
+func (e *WorkspaceError) Unwrap() error {
+	return e.Err
+}
+
+func (e *QueryError) Error() string {
+	message := e.Message
+	for inner := e.InnerError; inner != nil; inner = inner.InnerError {
+		if inner.Message != "" {
+			message += ": " + inner.Message
+		}
+	}
+
+	if e.Code != "" {
+		return fmt.Sprintf("%v: %v", e.Code, message)
+	}
+	return message
+}
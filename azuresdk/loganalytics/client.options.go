@@ -0,0 +1,47 @@
+package loganalytics
+
+import (
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+type ClientOptionFunc func(*Client)
+
+// WithEndpoint sets the Log Analytics endpoint and token audience (eg. for testing or custom clouds)
+func WithEndpoint(endpoint, audience string) ClientOptionFunc {
+	return func(client *Client) {
+		client.endpoint = endpoint
+		client.audience = audience
+	}
+}
+
+// WithHTTPClient sets the HTTP client which is used as transport
+func WithHTTPClient(httpClient *http.Client) ClientOptionFunc {
+	return func(client *Client) {
+		client.clientOptions.Transport = httpClient
+	}
+}
+
+// WithRetry sets the retry options of the azcore pipeline
+func WithRetry(retry policy.RetryOptions) ClientOptionFunc {
+	return func(client *Client) {
+		client.clientOptions.Retry = retry
+	}
+}
+
+// WithConcurrency sets the number of workspaces which are queried in parallel
+func WithConcurrency(concurrency int) ClientOptionFunc {
+	return func(client *Client) {
+		if concurrency >= 1 {
+			client.concurrency = concurrency
+		}
+	}
+}
+
+// WithWorkspaceField adds the workspace id as field to each row (eg. to use it as label)
+func WithWorkspaceField(field string) ClientOptionFunc {
+	return func(client *Client) {
+		client.workspaceField = field
+	}
+}
@@ -0,0 +1,215 @@
+package loganalytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/webdevops/go-common/azuresdk/armclient"
+	"github.com/webdevops/go-common/azuresdk/cloudconfig"
+	"github.com/webdevops/go-common/prometheus/kusto"
+)
+
+type (
+	testCredential struct{}
+
+	testWorkspace struct {
+		status   int
+		response string
+	}
+
+	testServer struct {
+		mux        sync.Mutex
+		workspaces map[string]testWorkspace
+		requests   map[string]queryRequest
+		auth       []string
+	}
+)
+
+func (c *testCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token:" + strings.Join(options.Scopes, ","), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	workspace := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/v1/workspaces/"), "/query")
+
+	body, _ := io.ReadAll(req.Body)
+	request := queryRequest{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.requests[workspace] = request
+	s.auth = append(s.auth, req.Header.Get("Authorization"))
+
+	response, exists := s.workspaces[workspace]
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.status)
+	_, _ = w.Write([]byte(response.response))
+}
+
+func newTestClient(t *testing.T, server *httptest.Server, opts ...ClientOptionFunc) *Client {
+	t.Helper()
+
+	var cred azcore.TokenCredential = &testCredential{}
+	cloudConfig := cloudconfig.CloudEnvironment{
+		Name: cloudconfig.AzurePrivateCloud,
+		Configuration: cloud.Configuration{
+			Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+				cloudconfig.ServiceNameLogAnalyticsWorkspace: {Endpoint: server.URL, Audience: "https://api.loganalytics.test/"},
+			},
+		},
+	}
+	armClient := armclient.NewArmClient(cloudConfig, slog.New(slog.DiscardHandler), armclient.WithCred(&cred))
+
+	opts = append([]ClientOptionFunc{WithHTTPClient(server.Client()), WithRetry(policy.RetryOptions{MaxRetries: -1})}, opts...)
+	client, err := NewClient(armClient, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func Test_ExecuteQuery(t *testing.T) {
+	handler := &testServer{
+		requests: map[string]queryRequest{},
+		workspaces: map[string]testWorkspace{
+			"ws-1": {http.StatusOK, `{"tables":[{"name":"PrimaryResult","columns":[{"name":"resourceId","type":"string"},{"name":"count_","type":"long"}],"rows":[["/foo",5],["/bar",2]]}]}`},
+			"ws-2": {http.StatusOK, `{"tables":[{"name":"PrimaryResult","columns":[{"name":"resourceId","type":"string"},{"name":"count_","type":"long"}],"rows":[["/baz",1]]}],"error":{"code":"PartialError","message":"There were some errors when processing your query.","details":[{"code":"EngineError","message":"Query result set has exceeded the internal record count limit"}]}}`},
+			"ws-3": {http.StatusBadRequest, `{"error":{"code":"BadArgumentError","message":"The request had some invalid properties"}}`},
+		},
+	}
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	client := newTestClient(t, server, WithWorkspaceField("workspaceId"))
+
+	query := kusto.Query{
+		QueryMetric: &kusto.QueryMetric{
+			Fields: []kusto.MetricField{{Name: "count_", Type: kusto.MetricFieldTypeValue}},
+		},
+		Metric:     "azure_loganalytics_count",
+		Query:      "Heartbeat | summarize count() by resourceId",
+		Timespan:   func() *string { val := "P1D"; return &val }(),
+		Workspaces: &[]string{"ws-1", "ws-2", "ws-3"},
+	}
+
+	result, err := client.ExecuteQuery(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Rows) != 3 || result.Rows[0]["resourceId"] != "/foo" || result.Rows[2]["workspaceId"] != "ws-2" {
+		t.Errorf("unexpected rows: %v", result.Rows)
+	}
+
+	if !result.IsPartial() || len(result.Errors) != 2 {
+		t.Fatalf("expected partial result with 2 errors, got %v", result.Errors)
+	}
+
+	if !result.Errors[0].Partial || result.Errors[0].Workspace != "ws-2" {
+		t.Errorf("expected partial error of ws-2, got %v", result.Errors[0])
+	}
+
+	var responseErr *azcore.ResponseError
+	if result.Errors[1].Partial || !errors.As(result.Errors[1], &responseErr) || responseErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected response error of ws-3, got %v", result.Errors[1])
+	}
+
+	if request := handler.requests["ws-1"]; request.Query != query.Query || request.Timespan != "P1D" {
+		t.Errorf("unexpected request: %v", request)
+	}
+
+	for _, auth := range handler.auth {
+		if auth != "Bearer token:https://api.loganalytics.test/.default" {
+			t.Errorf("unexpected authorization header: %v", auth)
+		}
+	}
+
+	metrics := kusto.BuildPrometheusMetricList(query.Metric, *query.QueryMetric, result.Rows[0])
+	if rows := metrics[query.Metric]; len(rows) != 1 || *rows[0].Value != 5 || rows[0].Labels["resourceId"] != "/foo" || rows[0].Labels["workspaceId"] != "ws-1" {
+		t.Errorf("unexpected metric list: %v", metrics)
+	}
+}
+
+func Test_ExecuteQueryFailed(t *testing.T) {
+	handler := &testServer{requests: map[string]queryRequest{}, workspaces: map[string]testWorkspace{}}
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+
+	query := kusto.Query{QueryMetric: &kusto.QueryMetric{}, Query: "Heartbeat", Workspaces: &[]string{"ws-1", "ws-2"}}
+	result, err := client.ExecuteQuery(context.Background(), query)
+	if err == nil || len(result.Errors) != 2 {
+		t.Fatalf("expected error for all workspaces, got %v", err)
+	}
+
+	var responseErr *azcore.ResponseError
+	if !errors.As(err, &responseErr) || responseErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected response error with status code 404, got %v", err)
+	}
+
+	if _, err := client.ExecuteQuery(context.Background(), kusto.Query{QueryMetric: &kusto.QueryMetric{}, Query: "Heartbeat"}); err == nil {
+		t.Error("expected error for query without workspaces")
+	}
+}
+
+func Test_ExecuteQueryResourceGraph(t *testing.T) {
+	handler := &testServer{requests: map[string]queryRequest{}, workspaces: map[string]testWorkspace{}}
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+
+	var options armclient.ResourceGraphOptions
+	client.resourceGraphQuery = func(ctx context.Context, query string, opts armclient.ResourceGraphOptions) ([]map[string]interface{}, error) {
+		options = opts
+		return []map[string]interface{}{{"id": "/subscriptions/foo/resourceGroups/bar", "count_": float64(3)}}, nil
+	}
+
+	query := kusto.Query{
+		QueryMetric:      &kusto.QueryMetric{},
+		QueryMode:        kusto.QueryModeResourceGraph,
+		Query:            "resources | summarize count() by id",
+		Subscriptions:    &[]string{"foo"},
+		ManagementGroups: &[]string{"root"},
+	}
+
+	result, err := client.ExecuteQuery(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Rows) != 1 || result.Rows[0]["count_"] != float64(3) {
+		t.Errorf("unexpected rows: %v", result.Rows)
+	}
+
+	if len(options.Subscriptions) != 1 || options.Subscriptions[0] != "foo" || len(options.ManagementGroups) != 1 || options.ManagementGroups[0] != "root" {
+		t.Errorf("unexpected ResourceGraph options: %+v", options)
+	}
+
+	if len(handler.requests) != 0 {
+		t.Errorf("expected no Log Analytics requests, got %v", handler.requests)
+	}
+}
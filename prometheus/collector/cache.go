@@ -176,7 +176,12 @@ func (c *Collector) collectionRestoreCache() bool {
 	}
 
 	if cacheContent, exists := c.cacheRead(); exists {
-		restoredData := NewCollectorData()
+		restoredData := struct {
+			*CollectorData
+			Metrics map[string]json.RawMessage `json:"metrics"`
+		}{
+			CollectorData: NewCollectorData(),
+		}
 
 		c.logger.Info(`restoring state from cache`, slog.String("cacheSpec", c.cache.raw))
 
@@ -194,13 +199,12 @@ func (c *Collector) collectionRestoreCache() bool {
 				// restore data
 				c.data.Expiry = restoredData.Expiry
 				for name, restoreMetricList := range restoredData.Metrics {
-					if restoreMetricList.List == nil {
-						continue
-					}
-
-					if metricList, exists := c.data.Metrics[name]; exists {
-						metricList.List = restoreMetricList.List
-						metricList.Init()
+					if metricList, exists := c.data.MetricLists[name]; exists {
+						if err := metricList.restore(restoreMetricList); err != nil {
+							c.logger.Warn(`unable to restore metric list from cache`, slog.String("list", name), slog.Any("error", err))
+							c.ReportError(NewError(ErrorKindCache, err))
+							return false
+						}
 					}
 				}
 
@@ -223,6 +227,7 @@ func (c *Collector) collectionRestoreCache() bool {
 			}
 		} else {
 			c.logger.Warn(`unable to decode cache`, slog.Any("error", err))
+			c.ReportError(NewError(ErrorKindCache, err))
 		}
 	}
 
@@ -245,6 +250,7 @@ func (c *Collector) collectionSaveCache() {
 		c.logger.Info(`saved state to cache`, slog.String("cacheSpec", c.cache.raw), slog.Time("expiry", c.data.Expiry.UTC()))
 	} else {
 		c.logger.Error(`failed to serialize state for cache`, slog.Any("error", err.Error()))
+		c.ReportError(NewError(ErrorKindCache, err))
 	}
 
 }
@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	testProcessor struct {
+		Processor
+	}
+
+	testTypedRow struct {
+		Name  string `label:"name"`
+		Value float64
+	}
+)
+
+func (p *testProcessor) Reset() {}
+
+func (p *testProcessor) Collect(callback chan<- func()) {}
+
+func newTestCollector(t *testing.T, name, cacheSpec string) *Collector {
+	t.Helper()
+
+	c := New(name, &testProcessor{}, slog.New(slog.DiscardHandler))
+	c.SetPrometheusRegistry(prometheus.NewRegistry())
+	c.SetScapeTime(1 * time.Minute)
+	c.SetNextSleepDuration(1 * time.Minute)
+	if err := c.SetCache(&cacheSpec, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	RegisterTypedMetricList[testTypedRow](c, "typed", prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_typed"}, []string{"name"}), true)
+	c.RegisterMetricList("list", prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_list"}, []string{"name"}), true)
+	c.RegisterHashedMetricList("hashed", prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_hashed"}, []string{"name"}), true)
+
+	return c
+}
+
+func Test_CollectorCacheRestore(t *testing.T) {
+	cacheSpec := "file://" + filepath.Join(t.TempDir(), "cache.json")
+
+	c := newTestCollector(t, "cache-store", cacheSpec)
+	GetTypedMetricList[testTypedRow](c, "typed").Add(testTypedRow{Name: "foo", Value: 12})
+	c.GetMetricList("list").Add(prometheus.Labels{"name": "bar"}, 3)
+	c.GetHashedMetricList("hashed").Inc(prometheus.Labels{"name": "baz"})
+	c.GetHashedMetricList("hashed").Inc(prometheus.Labels{"name": "baz"})
+	c.collectionStart()
+	c.collectionSaveCache()
+
+	restored := newTestCollector(t, "cache-restore", cacheSpec)
+	if !restored.collectionRestoreCache() {
+		t.Fatal("expected cache restore")
+	}
+
+	if list := GetTypedMetricList[testTypedRow](restored, "typed").GetList(); len(list) != 1 || list[0].Name != "foo" || list[0].Value != 12 {
+		t.Errorf("unexpected restored typed list: %v", list)
+	}
+
+	if list := restored.GetMetricList("list").GetList(); len(list) != 1 || list[0].Labels["name"] != "bar" || list[0].Value != 3 {
+		t.Errorf("unexpected restored list: %v", list)
+	}
+
+	if list := restored.GetHashedMetricList("hashed").GetList(); len(list) != 1 || list[0].Labels["name"] != "baz" || list[0].Value != 2 {
+		t.Errorf("unexpected restored hashed list: %v", list)
+	}
+
+	// deprecated Metrics field only contains *MetricList
+	if _, exists := restored.data.Metrics["list"]; !exists || len(restored.data.Metrics) != 1 || len(restored.data.MetricLists) != 3 {
+		t.Errorf("unexpected metric lists: %v, %v", restored.data.Metrics, restored.data.MetricLists)
+	}
+}
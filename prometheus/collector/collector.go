@@ -40,6 +40,17 @@ type Collector struct {
 		backoff   []time.Duration
 	}
 
+	changes struct {
+		hooks    []ChangeHook
+		previous map[string]changeSnapshot
+	}
+
+	lint metricLintConfig
+
+	errors collectorErrors
+
+	seriesExpiry map[string]seriesExpiry
+
 	data *CollectorData
 
 	registry *prometheus.Registry
@@ -53,8 +64,14 @@ type Collector struct {
 }
 
 type CollectorData struct {
-	// metric lists
-	Metrics map[string]*MetricList `json:"metrics"`
+	// metric lists registered with RegisterMetricList or RegisterMetricListCollector
+	//
+	// Deprecated: only contains *MetricList, use MetricLists which contains all managed metric lists
+	// (including hashed and typed metric lists)
+	Metrics map[string]*MetricList `json:"-"`
+
+	// all managed metric lists (serialized as "metrics" in the cache)
+	MetricLists map[string]MetricListInterface `json:"metrics"`
 
 	// custom data
 	Data map[string]interface{} `json:"data"`
@@ -72,9 +89,10 @@ type CollectorData struct {
 // NewCollectorData creates new collector data struct
 func NewCollectorData() *CollectorData {
 	return &CollectorData{
-		Metrics: map[string]*MetricList{},
-		Data:    map[string]interface{}{},
-		Expiry:  nil,
+		Metrics:     map[string]*MetricList{},
+		MetricLists: map[string]MetricListInterface{},
+		Data:        map[string]interface{}{},
+		Expiry:      nil,
 	}
 }
 
@@ -96,12 +114,16 @@ func New(name string, processor ProcessorInterface, logger *slog.Logger) *Collec
 	if logger != nil {
 		c.logger = logger.With(slog.String(`collector`, name))
 	}
+	c.lint = defaultLint
 	processor.Setup(c)
 
 	addCollectorToList(c)
 
 	metricInfo.WithLabelValues(c.Name).Set(1)
 	metricPanicCount.WithLabelValues(c.Name).Add(0)
+	for _, kind := range ErrorKinds {
+		metricErrors.WithLabelValues(c.Name, kind).Add(0)
+	}
 
 	return c
 }
@@ -296,6 +318,7 @@ func (c *Collector) runCacheRestore() (result bool) {
 
 			// try to restore metrics from cache
 			c.collectRun(false)
+			c.processChangeEvents()
 			result = true
 		}()
 	}
@@ -318,6 +341,8 @@ func (c *Collector) run() {
 
 	// metrics could not be restored from cache, start collect run
 	if c.collectRun(true) {
+		c.processSuccessfulRun()
+		c.processChangeEvents()
 		c.collectionSaveCache()
 	} else {
 		metricSuccess.WithLabelValues(c.Name).Set(0)
@@ -342,6 +367,7 @@ func (c *Collector) run() {
 // collectRun starts collector run and handles panics
 func (c *Collector) collectRun(doCollect bool) bool {
 	finished := false
+	failed := false
 	var panicDetected bool
 	var callbackList []func()
 
@@ -361,6 +387,7 @@ func (c *Collector) collectRun(doCollect bool) bool {
 					panicCounter := atomic.LoadInt64(&c.panic.counter)
 					if c.panic.threshold == -1 || panicCounter <= c.panic.threshold {
 						if err := recover(); err != nil {
+							c.reportPanic(err)
 							switch v := err.(type) {
 							case error:
 								c.logger.Error(fmt.Sprintf("panic occurred (panic threshold %v of %v): ", panicCounter, c.panic.threshold), slog.Any("error", v.Error()))
@@ -379,6 +406,8 @@ func (c *Collector) collectRun(doCollect bool) bool {
 
 			c.processor.Collect(callbackChannel)
 			c.waitGroup.Wait()
+			failed = !c.processDuplicates()
+			c.processSeriesLimits()
 			finished = true
 		}()
 
@@ -400,20 +429,13 @@ func (c *Collector) collectRun(doCollect bool) bool {
 	}
 
 	// set metrics from metrics
-	for _, metric := range c.data.Metrics {
-		switch vec := metric.vec.(type) {
-		case *prometheus.GaugeVec:
-			metric.GaugeSet(vec)
-		case *prometheus.HistogramVec:
-			metric.HistogramSet(vec)
-		case *prometheus.SummaryVec:
-			metric.SummarySet(vec)
-		case *prometheus.CounterVec:
-			metric.CounterAdd(vec)
-		}
+	for _, metric := range c.data.MetricLists {
+		metric.setVec()
 	}
 
-	return finished
+	c.processSeriesTTL()
+
+	return finished && !failed
 }
 
 // resetMetrics calls processor reset and resets registered metrics (if reset is enabled)
@@ -422,21 +444,11 @@ func (c *Collector) resetMetrics() {
 	c.processor.Reset()
 
 	// reset first
-	for _, metric := range c.data.Metrics {
-		if metric.reset {
-			switch vec := metric.vec.(type) {
-			case *prometheus.GaugeVec:
-				vec.Reset()
-			case *prometheus.HistogramVec:
-				vec.Reset()
-			case *prometheus.SummaryVec:
-				vec.Reset()
-			case *prometheus.CounterVec:
-				vec.Reset()
-			}
+	for _, metric := range c.data.MetricLists {
+		if metric.isResetEnabled() {
+			resetVec(metric.getVec())
 		}
 	}
-
 }
 
 // SetData stores additional data which also is stored/restored in cache
@@ -453,52 +465,126 @@ func (c *Collector) GetData(name string) interface{} {
 }
 
 // RegisterMetricList register new managed prometheus metric vec
-func (c *Collector) RegisterMetricList(name string, vec interface{}, reset bool) *MetricList {
-	c.data.Metrics[name] = &MetricList{
+func (c *Collector) RegisterMetricList(name string, vec interface{}, reset bool, opts ...MetricListOptionFunc) *MetricList {
+	metricList := &MetricList{
 		MetricList: prometheusCommon.NewMetricsList(),
 		vec:        vec,
 		reset:      reset,
+		options:    newMetricListOptions(opts...),
+	}
+	c.lintMetricList(name, metricList)
+	c.data.MetricLists[name] = metricList
+	c.data.Metrics[name] = metricList
+
+	c.registerVec(vec)
+
+	return metricList
+}
+
+// RegisterHashedMetricList register new managed prometheus metric vec with a hashed metric list
+func (c *Collector) RegisterHashedMetricList(name string, vec interface{}, reset bool, opts ...MetricListOptionFunc) *HashedMetricList {
+	metricList := &HashedMetricList{
+		HashedMetricList: prometheusCommon.NewHashedMetricsList(),
+		vec:              vec,
+		reset:            reset,
+		options:          newMetricListOptions(opts...),
+	}
+	c.lintMetricList(name, metricList)
+	c.data.MetricLists[name] = metricList
+
+	c.registerVec(vec)
+
+	return metricList
+}
+
+// RegisterTypedMetricList register new managed prometheus metric vec with a typed metric list
+//
+//	vec has to be created with the label names of the typed metric list (see TypedMetricList.LabelNames)
+func RegisterTypedMetricList[T any](c *Collector, name string, vec interface{}, reset bool, opts ...MetricListOptionFunc) *TypedMetricList[T] {
+	metricList := &TypedMetricList[T]{
+		TypedMetricList: prometheusCommon.NewTypedMetricList[T](),
+		vec:             vec,
+		reset:           reset,
+		options:         newMetricListOptions(opts...),
 	}
+	c.lintMetricList(name, metricList)
+	c.data.MetricLists[name] = metricList
 
+	c.registerVec(vec)
+
+	return metricList
+}
+
+// RegisterMetricListCollector register new managed metric list which is exported as prometheus.Collector
+//
+//	metrics are exported as const metrics from the last finished run, no prometheus vec is needed
+func (c *Collector) RegisterMetricListCollector(name string, desc prometheusCommon.MetricDesc, opts ...MetricListOptionFunc) *MetricList {
+	metricList := &MetricList{
+		MetricList: prometheusCommon.NewMetricsList(),
+		options:    newMetricListOptions(opts...),
+	}
+	metricList.SetDesc(desc)
+	c.lintMetricList(name, metricList)
+	c.data.MetricLists[name] = metricList
+	c.data.Metrics[name] = metricList
+
+	c.register(metricList.MetricList)
+
+	return metricList
+}
+
+// registerVec registers prometheus metric vec in registry
+func (c *Collector) registerVec(vec interface{}) {
+	switch vec := vec.(type) {
+	case *prometheus.GaugeVec:
+		c.register(vec)
+	case *prometheus.HistogramVec:
+		c.register(vec)
+	case *prometheus.SummaryVec:
+		c.register(vec)
+	case *prometheus.CounterVec:
+		c.register(vec)
+	default:
+		panic(`not allowed prometheus metric vec found`)
+	}
+}
+
+// register registers prometheus collector in registry (or default registry if not set)
+func (c *Collector) register(collector prometheus.Collector) {
 	if c.registry != nil {
-		switch vec := vec.(type) {
-		case *prometheus.GaugeVec:
-			c.registry.MustRegister(vec)
-		case *prometheus.HistogramVec:
-			c.registry.MustRegister(vec)
-		case *prometheus.SummaryVec:
-			c.registry.MustRegister(vec)
-		case *prometheus.CounterVec:
-			c.registry.MustRegister(vec)
-		default:
-			panic(`not allowed prometheus metric vec found`)
-		}
+		c.registry.MustRegister(collector)
 	} else {
-		switch vec := vec.(type) {
-		case *prometheus.GaugeVec:
-			prometheus.MustRegister(vec)
-		case *prometheus.HistogramVec:
-			prometheus.MustRegister(vec)
-		case *prometheus.SummaryVec:
-			prometheus.MustRegister(vec)
-		case *prometheus.CounterVec:
-			prometheus.MustRegister(vec)
-		default:
-			panic(`not allowed prometheus metric vec found`)
-		}
+		prometheus.MustRegister(collector)
 	}
-
-	return c.data.Metrics[name]
 }
 
 // GetMetricList returns managed metric vec
 func (c *Collector) GetMetricList(name string) *MetricList {
-	return c.data.Metrics[name]
+	if metricList, ok := c.data.MetricLists[name].(*MetricList); ok {
+		return metricList
+	}
+	return nil
+}
+
+// GetHashedMetricList returns managed hashed metric vec
+func (c *Collector) GetHashedMetricList(name string) *HashedMetricList {
+	if metricList, ok := c.data.MetricLists[name].(*HashedMetricList); ok {
+		return metricList
+	}
+	return nil
+}
+
+// GetTypedMetricList returns managed typed metric vec
+func GetTypedMetricList[T any](c *Collector, name string) *TypedMetricList[T] {
+	if metricList, ok := c.data.MetricLists[name].(*TypedMetricList[T]); ok {
+		return metricList
+	}
+	return nil
 }
 
 // cleanupMetricLists resets all registered metric vec
 func (c *Collector) cleanupMetricLists() {
-	for _, metric := range c.data.Metrics {
+	for _, metric := range c.data.MetricLists {
 		metric.Reset()
 	}
 }
@@ -507,6 +593,7 @@ func (c *Collector) cleanupMetricLists() {
 func (c *Collector) collectionStart() {
 	c.collectionStartTime = time.Now()
 	c.lastScrapeTime = nil
+	c.resetErrors()
 }
 
 // collectionFinish processes collection finish
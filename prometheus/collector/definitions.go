@@ -0,0 +1,335 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/yaml"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+const (
+	MetricDefinitionTypeGauge     = "gauge"
+	MetricDefinitionTypeCounter   = "counter"
+	MetricDefinitionTypeHistogram = "histogram"
+	MetricDefinitionTypeSummary   = "summary"
+
+	MetricDefinitionResetRun   = "run"
+	MetricDefinitionResetNever = "never"
+)
+
+type (
+	// MetricDefinitions contains declarative metric definitions (eg. loaded from YAML or JSON)
+	//
+	//	metrics:
+	//	  - name: azurerm_resource_info
+	//	    type: gauge
+	//	    help: Azure resource information
+	//	    labels: [resourceID, location]
+	//	  - name: azurerm_request_duration_seconds
+	//	    type: histogram
+	//	    labels: [resourceID]
+	//	    buckets: [0.1, 0.5, 1, 5]
+	//	    reset: never
+	//	    ttl: 1h
+	MetricDefinitions struct {
+		Metrics []MetricDefinition `json:"metrics"`
+	}
+
+	MetricDefinition struct {
+		Name        string            `json:"name"`
+		List        string            `json:"list"`
+		Type        string            `json:"type"`
+		Help        string            `json:"help"`
+		Labels      []string          `json:"labels"`
+		ConstLabels map[string]string `json:"constLabels"`
+		Buckets     []float64         `json:"buckets"`
+		Reset       string            `json:"reset"`
+		TTL         string            `json:"ttl"`
+		SeriesLimit int               `json:"seriesLimit"`
+
+		ttl time.Duration
+	}
+
+	// DefinedMetrics contains the metric lists which were registered from metric definitions
+	DefinedMetrics struct {
+		lists map[string]*DefinedMetric
+	}
+
+	DefinedMetric struct {
+		*MetricList
+		Definition MetricDefinition
+	}
+
+	// GaugeMetric sets the values of a gauge metric
+	GaugeMetric struct {
+		*DefinedMetric
+	}
+
+	// CounterMetric adds values to a counter metric
+	CounterMetric struct {
+		*DefinedMetric
+	}
+
+	// ObserverMetric observes values of a histogram or summary metric
+	ObserverMetric struct {
+		*DefinedMetric
+	}
+)
+
+// LoadMetricDefinitions reads metric definitions from YAML or JSON file
+func LoadMetricDefinitions(path string) (*MetricDefinitions, error) {
+	/*  #nosec G304 */
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := ParseMetricDefinitions(data)
+	if err != nil {
+		return nil, fmt.Errorf(`metric definitions "%v": %w`, path, err)
+	}
+
+	return definitions, nil
+}
+
+// ParseMetricDefinitions parses and validates metric definitions from YAML or JSON
+func ParseMetricDefinitions(data []byte) (*MetricDefinitions, error) {
+	definitions := &MetricDefinitions{}
+	if err := yaml.UnmarshalStrict(data, definitions); err != nil {
+		return nil, err
+	}
+
+	if err := definitions.Validate(); err != nil {
+		return nil, err
+	}
+
+	return definitions, nil
+}
+
+// Validate checks all metric definitions
+func (d *MetricDefinitions) Validate() error {
+	lists := map[string]bool{}
+	names := map[string]bool{}
+	for i := range d.Metrics {
+		definition := &d.Metrics[i]
+		if err := definition.Validate(); err != nil {
+			return fmt.Errorf(`metric "%v": %w`, definition.Name, err)
+		}
+
+		if names[definition.Name] {
+			return fmt.Errorf(`metric "%v": metric is defined multiple times`, definition.Name)
+		}
+		names[definition.Name] = true
+
+		if lists[definition.GetList()] {
+			return fmt.Errorf(`metric "%v": list "%v" is defined multiple times`, definition.Name, definition.GetList())
+		}
+		lists[definition.GetList()] = true
+	}
+
+	return nil
+}
+
+// Validate checks the metric definition
+func (d *MetricDefinition) Validate() error {
+	if !prometheusCommon.IsValidMetricName(d.Name) {
+		return errors.New("invalid metric name")
+	}
+
+	switch d.GetType() {
+	case MetricDefinitionTypeGauge, MetricDefinitionTypeCounter, MetricDefinitionTypeSummary:
+		if len(d.Buckets) > 0 {
+			return fmt.Errorf(`buckets are not supported by type "%v"`, d.GetType())
+		}
+	case MetricDefinitionTypeHistogram:
+		if !slices.IsSorted(d.Buckets) || len(slices.Compact(slices.Clone(d.Buckets))) != len(d.Buckets) {
+			return errors.New("buckets have to be sorted and unique")
+		}
+	default:
+		return fmt.Errorf(`unsupported type "%v"`, d.Type)
+	}
+
+	for _, labelName := range d.Labels {
+		if !prometheusCommon.IsValidLabelName(labelName) {
+			return fmt.Errorf(`invalid label name "%v"`, labelName)
+		}
+	}
+
+	for labelName := range d.ConstLabels {
+		if !prometheusCommon.IsValidLabelName(labelName) || slices.Contains(d.Labels, labelName) {
+			return fmt.Errorf(`invalid const label name "%v"`, labelName)
+		}
+	}
+
+	switch d.GetReset() {
+	case MetricDefinitionResetRun, MetricDefinitionResetNever:
+	default:
+		return fmt.Errorf(`unsupported reset mode "%v"`, d.Reset)
+	}
+
+	d.ttl = 0
+	if d.TTL != "" {
+		ttl, err := time.ParseDuration(d.TTL)
+		if err != nil {
+			return fmt.Errorf(`invalid ttl "%v": %w`, d.TTL, err)
+		}
+
+		if d.GetReset() != MetricDefinitionResetNever {
+			return fmt.Errorf(`ttl needs reset mode "%v"`, MetricDefinitionResetNever)
+		}
+		d.ttl = ttl
+	}
+
+	if d.SeriesLimit < 0 {
+		return errors.New("seriesLimit must not be negative")
+	}
+
+	return nil
+}
+
+// GetType returns the metric type (gauge if not set)
+func (d *MetricDefinition) GetType() (ret string) {
+	ret = strings.ToLower(d.Type)
+	if ret == "" {
+		ret = MetricDefinitionTypeGauge
+	}
+	return
+}
+
+// GetList returns the name of the metric list (metric name if not set)
+func (d *MetricDefinition) GetList() string {
+	if d.List != "" {
+		return d.List
+	}
+	return d.Name
+}
+
+// GetReset returns the reset mode (reset on each run if not set)
+func (d *MetricDefinition) GetReset() (ret string) {
+	ret = strings.ToLower(d.Reset)
+	if ret == "" {
+		ret = MetricDefinitionResetRun
+	}
+	return
+}
+
+// newVec creates the prometheus vec of the metric definition
+func (d *MetricDefinition) newVec() interface{} {
+	constLabels := prometheus.Labels(d.ConstLabels)
+
+	switch d.GetType() {
+	case MetricDefinitionTypeCounter:
+		return prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: d.Name, Help: d.Help, ConstLabels: constLabels},
+			d.Labels,
+		)
+	case MetricDefinitionTypeHistogram:
+		buckets := d.Buckets
+		if len(buckets) == 0 {
+			buckets = prometheus.DefBuckets
+		}
+		return prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{Name: d.Name, Help: d.Help, ConstLabels: constLabels, Buckets: buckets},
+			d.Labels,
+		)
+	case MetricDefinitionTypeSummary:
+		return prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{Name: d.Name, Help: d.Help, ConstLabels: constLabels},
+			d.Labels,
+		)
+	default:
+		return prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: d.Name, Help: d.Help, ConstLabels: constLabels},
+			d.Labels,
+		)
+	}
+}
+
+// RegisterMetricDefinitions creates the prometheus vecs of the definitions and registers them as metric lists
+func (c *Collector) RegisterMetricDefinitions(definitions *MetricDefinitions) (*DefinedMetrics, error) {
+	if err := definitions.Validate(); err != nil {
+		return nil, err
+	}
+
+	ret := &DefinedMetrics{lists: map[string]*DefinedMetric{}}
+	for _, definition := range definitions.Metrics {
+		opts := []MetricListOptionFunc{}
+		if definition.SeriesLimit > 0 {
+			opts = append(opts, WithSeriesLimit(definition.SeriesLimit))
+		}
+		if definition.ttl > 0 {
+			opts = append(opts, WithSeriesTTL(definition.ttl))
+		}
+
+		reset := definition.GetReset() == MetricDefinitionResetRun
+		ret.lists[definition.GetList()] = &DefinedMetric{
+			MetricList: c.RegisterMetricList(definition.GetList(), definition.newVec(), reset, opts...),
+			Definition: definition,
+		}
+	}
+
+	return ret, nil
+}
+
+// Get returns the defined metric of the list (or nil if not defined)
+func (m *DefinedMetrics) Get(list string) *DefinedMetric {
+	return m.lists[list]
+}
+
+// Gauge returns the gauge handle of the list (or nil if not defined as gauge)
+func (m *DefinedMetrics) Gauge(list string) *GaugeMetric {
+	if metric := m.getWithType(list, MetricDefinitionTypeGauge); metric != nil {
+		return &GaugeMetric{metric}
+	}
+	return nil
+}
+
+// Counter returns the counter handle of the list (or nil if not defined as counter)
+func (m *DefinedMetrics) Counter(list string) *CounterMetric {
+	if metric := m.getWithType(list, MetricDefinitionTypeCounter); metric != nil {
+		return &CounterMetric{metric}
+	}
+	return nil
+}
+
+// Observer returns the observer handle of the list (or nil if not defined as histogram or summary)
+func (m *DefinedMetrics) Observer(list string) *ObserverMetric {
+	if metric := m.getWithType(list, MetricDefinitionTypeHistogram, MetricDefinitionTypeSummary); metric != nil {
+		return &ObserverMetric{metric}
+	}
+	return nil
+}
+
+func (m *DefinedMetrics) getWithType(list string, types ...string) *DefinedMetric {
+	if metric, exists := m.lists[list]; exists && slices.Contains(types, metric.Definition.GetType()) {
+		return metric
+	}
+	return nil
+}
+
+// Set sets the value of the series
+func (m *GaugeMetric) Set(labels prometheus.Labels, value float64) {
+	m.Add(labels, value)
+}
+
+// Inc adds one to the series
+func (m *CounterMetric) Inc(labels prometheus.Labels) {
+	m.MetricList.Add(labels, 1)
+}
+
+// Add adds the value to the series
+func (m *CounterMetric) Add(labels prometheus.Labels, value float64) {
+	m.MetricList.Add(labels, value)
+}
+
+// Observe adds an observation to the series
+func (m *ObserverMetric) Observe(labels prometheus.Labels, value float64) {
+	m.Add(labels, value)
+}
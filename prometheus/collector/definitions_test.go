@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+const (
+	testMetricDefinitions = `
+metrics:
+  - name: test_definition_resource_info
+    help: Resource info
+    labels: [resourceID, location]
+  - name: test_definition_requests_total
+    list: requests
+    type: counter
+    help: Requests
+    labels: [resourceID]
+    reset: never
+    ttl: 1h
+  - name: test_definition_duration_seconds
+    type: histogram
+    help: Request duration
+    labels: [resourceID]
+    buckets: [0.1, 1]
+`
+)
+
+func Test_MetricDefinitions(t *testing.T) {
+	definitions, err := ParseMetricDefinitions([]byte(testMetricDefinitions))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	c := New("definitions", &testProcessor{}, slog.New(slog.DiscardHandler))
+	c.SetPrometheusRegistry(registry)
+
+	metrics, err := c.RegisterMetricDefinitions(definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metrics.Counter("test_definition_resource_info") != nil || metrics.Gauge("unknown") != nil {
+		t.Error("expected no handle for wrong type or unknown list")
+	}
+
+	metrics.Gauge("test_definition_resource_info").Set(prometheus.Labels{"resourceID": "/foo", "location": "westeurope"}, 1)
+	metrics.Counter("requests").Inc(prometheus.Labels{"resourceID": "/foo"})
+	metrics.Observer("test_definition_duration_seconds").Observe(prometheus.Labels{"resourceID": "/foo"}, 0.5)
+
+	if c.GetMetricList("requests").isResetEnabled() {
+		t.Error("expected reset to be disabled for requests")
+	}
+
+	c.collectRun(false)
+
+	expected := `
+# HELP test_definition_duration_seconds Request duration
+# TYPE test_definition_duration_seconds histogram
+test_definition_duration_seconds_bucket{resourceID="/foo",le="0.1"} 0
+test_definition_duration_seconds_bucket{resourceID="/foo",le="1"} 1
+test_definition_duration_seconds_bucket{resourceID="/foo",le="+Inf"} 1
+test_definition_duration_seconds_sum{resourceID="/foo"} 0.5
+test_definition_duration_seconds_count{resourceID="/foo"} 1
+# HELP test_definition_requests_total Requests
+# TYPE test_definition_requests_total counter
+test_definition_requests_total{resourceID="/foo"} 1
+# HELP test_definition_resource_info Resource info
+# TYPE test_definition_resource_info gauge
+test_definition_resource_info{location="westeurope",resourceID="/foo"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_MetricDefinitionsValidation(t *testing.T) {
+	invalid := map[string]string{
+		"invalid name":     `{"metrics": [{"name": "test-metric"}]}`,
+		"unknown type":     `{"metrics": [{"name": "test_metric", "type": "foo"}]}`,
+		"unsorted buckets": `{"metrics": [{"name": "test_metric", "type": "histogram", "buckets": [1, 0.1]}]}`,
+		"duplicate bucket": `{"metrics": [{"name": "test_metric", "type": "histogram", "buckets": [1, 1]}]}`,
+		"duplicate name":   `{"metrics": [{"name": "test_metric", "list": "a"}, {"name": "test_metric", "list": "b"}]}`,
+		"gauge buckets":    `{"metrics": [{"name": "test_metric", "buckets": [1]}]}`,
+		"invalid label":    `{"metrics": [{"name": "test_metric", "labels": ["__name"]}]}`,
+		"ttl with reset":   `{"metrics": [{"name": "test_metric", "ttl": "1h"}]}`,
+		"invalid ttl":      `{"metrics": [{"name": "test_metric", "reset": "never", "ttl": "foo"}]}`,
+		"duplicate list":   `{"metrics": [{"name": "test_metric"}, {"name": "test_metric"}]}`,
+		"unknown key":      `{"metrics": [{"name": "test_metric", "lables": ["foo"]}]}`,
+	}
+
+	for name, definition := range invalid {
+		if _, err := ParseMetricDefinitions([]byte(definition)); err == nil {
+			t.Errorf("%v: expected validation error", name)
+		}
+	}
+}
+
+func Test_CollectorSeriesTTL(t *testing.T) {
+	c := New("series-ttl", &testProcessor{}, slog.New(slog.DiscardHandler))
+	c.SetPrometheusRegistry(prometheus.NewRegistry())
+
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_series_ttl_total"}, []string{"name"})
+	list := c.RegisterMetricList("ttl", counter, false, WithSeriesTTL(time.Hour))
+
+	list.Add(prometheus.Labels{"name": "foo"}, 1)
+	list.Add(prometheus.Labels{"name": "bar"}, 1)
+	c.collectRun(false)
+
+	// foo was seen before the ttl
+	entry := c.seriesExpiry["ttl"][prometheusCommon.LabelSetKey(prometheus.Labels{"name": "foo"})]
+	entry.lastSeen = entry.lastSeen.Add(-2 * time.Hour)
+	c.seriesExpiry["ttl"][prometheusCommon.LabelSetKey(prometheus.Labels{"name": "foo"})] = entry
+
+	list.Reset()
+	list.Add(prometheus.Labels{"name": "bar"}, 1)
+	c.collectRun(false)
+
+	if count := testutil.CollectAndCount(counter); count != 1 {
+		t.Errorf("expected expired series to be removed, got %v series", count)
+	}
+
+	if value := testutil.ToFloat64(counter.WithLabelValues("bar")); value != 2 {
+		t.Errorf("expected counter value 2, got %v", value)
+	}
+}
@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+type (
+	metricListDeduplicator interface {
+		GetDuplicatePolicy() string
+		Deduplicate() (prometheusCommon.DuplicateResult, error)
+	}
+)
+
+// processDuplicates detects and merges duplicate series of all metric lists with duplicate policy
+//
+//	returns false if a list with DuplicatePolicyError contains duplicates (error is reported), so the run is handled as failed
+func (c *Collector) processDuplicates() (result bool) {
+	result = true
+	for name, metricList := range c.data.MetricLists {
+		list, ok := metricList.(metricListDeduplicator)
+		if !ok || list.GetDuplicatePolicy() == prometheusCommon.DuplicatePolicyNone {
+			continue
+		}
+
+		duplicates, err := list.Deduplicate()
+		if duplicates.Count == 0 {
+			continue
+		}
+
+		metricDuplicates.WithLabelValues(c.Name, name).Add(float64(duplicates.Count))
+
+		if err != nil {
+			err = fmt.Errorf(`metric list "%v": %w`, name, err)
+			c.logger.Error(`detected duplicate series in metric list`, slog.String("list", name), slog.Any("error", err.Error()))
+			c.ReportError(err)
+			result = false
+			continue
+		}
+
+		c.logger.Warn(
+			`detected duplicate series in metric list`,
+			slog.String("list", name),
+			slog.String("policy", list.GetDuplicatePolicy()),
+			slog.Int("count", duplicates.Count),
+			slog.Any("example", duplicates.Example),
+		)
+	}
+
+	return
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/remeh/sizedwaitgroup"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+type (
+	testCollectProcessor struct {
+		Processor
+		collect func()
+	}
+)
+
+func (p *testCollectProcessor) Reset() {}
+
+func (p *testCollectProcessor) Collect(callback chan<- func()) {
+	p.collect()
+}
+
+func Test_CollectorDuplicatePolicyError(t *testing.T) {
+	processor := &testCollectProcessor{}
+	c := New("duplicates", processor, slog.New(slog.DiscardHandler))
+	c.SetPrometheusRegistry(prometheus.NewRegistry())
+	// panics would be passed through immediately
+	c.SetPanicThreshold(0)
+	waitGroup := sizedwaitgroup.New(1)
+	c.waitGroup = &waitGroup
+
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_duplicates"}, []string{"name"})
+	list := c.RegisterMetricList("duplicates", gauge, true)
+	list.SetDuplicatePolicy(prometheusCommon.DuplicatePolicyError)
+
+	processor.collect = func() {
+		list.Add(prometheus.Labels{"name": "foo"}, 1)
+		list.Add(prometheus.Labels{"name": "foo"}, 2)
+	}
+
+	if c.collectRun(true) {
+		t.Error("expected failed run")
+	}
+
+	if value := testutil.ToFloat64(metricErrors.WithLabelValues("duplicates", ErrorKindOther)); value != 1 {
+		t.Errorf("expected 1 reported error, got %v", value)
+	}
+
+	if value := testutil.ToFloat64(metricPanicCount.WithLabelValues("duplicates")); value != 0 {
+		t.Errorf("expected no panic, got %v", value)
+	}
+
+	// run without duplicates
+	list.Reset()
+	processor.collect = func() {
+		list.Add(prometheus.Labels{"name": "foo"}, 1)
+	}
+
+	if !c.collectRun(true) {
+		t.Error("expected successful run")
+	}
+}
@@ -0,0 +1,208 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ErrorKindThrottled = "throttled"
+	ErrorKindAuth      = "auth"
+	ErrorKindTimeout   = "timeout"
+	ErrorKindNotFound  = "notfound"
+	ErrorKindPanic     = "panic"
+	ErrorKindCache     = "cache"
+	ErrorKindOther     = "other"
+
+	DefaultLastErrorMessageLength = 200
+)
+
+var (
+	// ErrorKinds contains all error kinds which are initialized for collector_errors_total
+	ErrorKinds = []string{
+		ErrorKindThrottled,
+		ErrorKindAuth,
+		ErrorKindTimeout,
+		ErrorKindNotFound,
+		ErrorKindPanic,
+		ErrorKindCache,
+		ErrorKindOther,
+	}
+
+	errorMessageUuidRegexp = regexp.MustCompile(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+)
+
+type (
+	// Error is an error with an explicit error kind (see ClassifyError)
+	Error struct {
+		Kind string
+		Err  error
+	}
+
+	collectorErrors struct {
+		mux sync.Mutex
+
+		// reported is true if an error was reported in the current run
+		reported bool
+
+		lastErrorInfo          bool
+		lastErrorMessageLength int
+	}
+)
+
+// NewError creates an error with an explicit error kind
+func NewError(kind string, err error) *Error {
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError returns the error kind (throttled, auth, timeout, notfound or other)
+func ClassifyError(err error) string {
+	var kindErr *Error
+	if errors.As(err, &kindErr) {
+		return kindErr.Kind
+	}
+
+	var responseErr *azcore.ResponseError
+	if errors.As(err, &responseErr) {
+		if kind := classifyStatusCode(responseErr.StatusCode); kind != ErrorKindOther {
+			return kind
+		}
+	}
+
+	var authErr *azidentity.AuthenticationFailedError
+	if errors.As(err, &authErr) {
+		return ErrorKindAuth
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorKindTimeout
+	}
+
+	return ErrorKindOther
+}
+
+// classifyStatusCode returns the error kind of a HTTP status code
+func classifyStatusCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return ErrorKindThrottled
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorKindAuth
+	case http.StatusNotFound:
+		return ErrorKindNotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrorKindTimeout
+	}
+	return ErrorKindOther
+}
+
+// EnableLastErrorInfo exports the last error of the collector as collector_last_error_info{collector,kind,message}
+//
+//	the message is sanitized (eg. UUIDs are masked) and limited to maxLength (0 = DefaultLastErrorMessageLength),
+//	the metric is removed after the next successful run without errors
+func (c *Collector) EnableLastErrorInfo(maxLength int) {
+	if maxLength <= 0 {
+		maxLength = DefaultLastErrorMessageLength
+	}
+
+	c.errors.mux.Lock()
+	defer c.errors.mux.Unlock()
+	c.errors.lastErrorInfo = true
+	c.errors.lastErrorMessageLength = maxLength
+}
+
+// ReportError counts the error in collector_errors_total (and collector_last_error_info if enabled)
+//
+//	can be used by processors for errors which do not stop the run (eg. a failed subscription)
+func (c *Collector) ReportError(err error) {
+	if err == nil {
+		return
+	}
+
+	c.reportError(ClassifyError(err), err.Error())
+}
+
+// reportPanic counts the recovered panic, errors are classified and fall back to the panic kind
+func (c *Collector) reportPanic(recovered interface{}) {
+	kind := ErrorKindPanic
+	message := fmt.Sprintf("%v", recovered)
+	if err, ok := recovered.(error); ok {
+		if errKind := ClassifyError(err); errKind != ErrorKindOther {
+			kind = errKind
+		}
+	}
+
+	c.reportError(kind, message)
+}
+
+func (c *Collector) reportError(kind, message string) {
+	metricErrors.WithLabelValues(c.Name, kind).Inc()
+
+	c.errors.mux.Lock()
+	defer c.errors.mux.Unlock()
+
+	c.errors.reported = true
+	if c.errors.lastErrorInfo {
+		metricLastErrorInfo.DeletePartialMatch(prometheus.Labels{"collector": c.Name})
+		metricLastErrorInfo.WithLabelValues(c.Name, kind, sanitizeErrorMessage(message, c.errors.lastErrorMessageLength)).Set(1)
+	}
+}
+
+// resetErrors starts a new run without reported errors
+func (c *Collector) resetErrors() {
+	c.errors.mux.Lock()
+	defer c.errors.mux.Unlock()
+	c.errors.reported = false
+}
+
+// processSuccessfulRun removes the last error info if no errors were reported during the run
+func (c *Collector) processSuccessfulRun() {
+	c.errors.mux.Lock()
+	defer c.errors.mux.Unlock()
+
+	if !c.errors.reported {
+		metricLastErrorInfo.DeletePartialMatch(prometheus.Labels{"collector": c.Name})
+	}
+}
+
+// sanitizeErrorMessage removes control characters, masks UUIDs and limits the length of the message
+func sanitizeErrorMessage(message string, maxLength int) string {
+	message = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, message)
+	message = strings.Join(strings.Fields(message), " ")
+	message = errorMessageUuidRegexp.ReplaceAllString(message, "<uuid>")
+
+	if runes := []rune(message); len(runes) > maxLength {
+		message = string(runes[:maxLength-1]) + "…"
+	}
+
+	return message
+}
@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+type (
+	testPanicProcessor struct {
+		Processor
+		err interface{}
+	}
+)
+
+func (p *testPanicProcessor) Reset() {}
+
+func (p *testPanicProcessor) Collect(callback chan<- func()) {
+	if p.err != nil {
+		panic(p.err)
+	}
+}
+
+func Test_ClassifyError(t *testing.T) {
+	errorKinds := map[string]error{
+		ErrorKindThrottled: &azcore.ResponseError{StatusCode: http.StatusTooManyRequests},
+		ErrorKindAuth:      fmt.Errorf("wrapped: %w", &azcore.ResponseError{StatusCode: http.StatusForbidden}),
+		ErrorKindNotFound:  &azcore.ResponseError{StatusCode: http.StatusNotFound},
+		ErrorKindTimeout:   fmt.Errorf("query failed: %w", context.DeadlineExceeded),
+		ErrorKindCache:     NewError(ErrorKindCache, errors.New("unable to decode cache")),
+		ErrorKindOther:     errors.New("something failed"),
+	}
+
+	for kind, err := range errorKinds {
+		if errKind := ClassifyError(err); errKind != kind {
+			t.Errorf("expected kind %v for %v, got %v", kind, err, errKind)
+		}
+	}
+}
+
+func Test_SanitizeErrorMessage(t *testing.T) {
+	message := sanitizeErrorMessage("subscription 00000000-0000-0000-0000-000000000000\nfailed:\tforbidden", 200)
+	if message != "subscription <uuid> failed: forbidden" {
+		t.Errorf("unexpected sanitized message: %q", message)
+	}
+
+	if message := sanitizeErrorMessage(strings.Repeat("x", 300), 10); len([]rune(message)) != 10 {
+		t.Errorf("expected message with 10 characters, got %q", message)
+	}
+}
+
+func Test_CollectorErrors(t *testing.T) {
+	processor := &testPanicProcessor{err: &azcore.ResponseError{StatusCode: http.StatusTooManyRequests, ErrorCode: "TooManyRequests"}}
+	c := New("errors", processor, slog.New(slog.DiscardHandler))
+	c.SetPrometheusRegistry(prometheus.NewRegistry())
+	c.SetScapeTime(0)
+	c.SetPanicBackoff()
+	c.EnableLastErrorInfo(0)
+	waitGroup := sizedwaitgroup.New(1)
+	c.waitGroup = &waitGroup
+
+	c.run()
+	if value := testutil.ToFloat64(metricErrors.WithLabelValues("errors", ErrorKindThrottled)); value != 1 {
+		t.Errorf("expected 1 throttled error, got %v", value)
+	}
+	if count := testutil.CollectAndCount(metricLastErrorInfo); count != 1 {
+		t.Errorf("expected last error info, got %v series", count)
+	}
+
+	processor.err = "unexpected state"
+	c.run()
+	if value := testutil.ToFloat64(metricErrors.WithLabelValues("errors", ErrorKindPanic)); value != 1 {
+		t.Errorf("expected 1 panic error, got %v", value)
+	}
+	if value := testutil.ToFloat64(metricLastErrorInfo.WithLabelValues("errors", ErrorKindPanic, "unexpected state")); value != 1 {
+		t.Errorf("expected last error info of panic, got %v", value)
+	}
+
+	// successful run resets the last error
+	processor.err = nil
+	c.run()
+	if count := testutil.CollectAndCount(metricLastErrorInfo); count != 0 {
+		t.Errorf("expected no last error info after success, got %v series", count)
+	}
+}
@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+const (
+	ChangeEventAdded   ChangeEventType = "added"
+	ChangeEventRemoved ChangeEventType = "removed"
+	ChangeEventChanged ChangeEventType = "changed"
+)
+
+type (
+	ChangeEventType string
+
+	// ChangeEvent describes a series which appeared, disappeared or changed its value between two runs
+	ChangeEvent struct {
+		Collector     string
+		List          string
+		Type          ChangeEventType
+		Labels        prometheus.Labels
+		Value         float64
+		PreviousValue float64
+	}
+
+	// ChangeHook is called for every change event after a finished collector run
+	ChangeHook func(event ChangeEvent)
+
+	changeSnapshot map[string]prometheusCommon.MetricRow
+)
+
+// AddChangeHook registers hooks which are called when series appear, disappear or change between runs
+//
+//	change tracking is only active if at least one hook is registered
+func (c *Collector) AddChangeHook(hooks ...ChangeHook) {
+	c.changes.hooks = append(c.changes.hooks, hooks...)
+}
+
+// NewSlogChangeHook creates a change hook which logs every change event with the passed logger and level
+func NewSlogChangeHook(logger *slog.Logger, level slog.Level) ChangeHook {
+	return func(event ChangeEvent) {
+		logger.LogAttrs(
+			context.Background(),
+			level,
+			"metric series "+string(event.Type),
+			slog.String("collector", event.Collector),
+			slog.String("list", event.List),
+			slog.Any("labels", event.Labels),
+			slog.Float64("value", event.Value),
+			slog.Float64("previousValue", event.PreviousValue),
+		)
+	}
+}
+
+// processChangeEvents diffs the current metric lists against the previous run and triggers the change hooks
+func (c *Collector) processChangeEvents() {
+	if len(c.changes.hooks) == 0 {
+		return
+	}
+
+	// first run only builds the baseline
+	emitEvents := c.changes.previous != nil
+
+	current := map[string]changeSnapshot{}
+	for name, metricList := range c.data.MetricLists {
+		current[name] = buildChangeSnapshot(metricList.GetMetricRows())
+
+		if !emitEvents {
+			continue
+		}
+
+		for _, event := range diffChangeSnapshot(c.changes.previous[name], current[name]) {
+			event.Collector = c.Name
+			event.List = name
+
+			metricChanges.WithLabelValues(c.Name, name, string(event.Type)).Inc()
+			for _, hook := range c.changes.hooks {
+				hook(event)
+			}
+		}
+	}
+
+	c.changes.previous = current
+}
+
+// buildChangeSnapshot builds a snapshot indexed by label set, last row wins (same as GaugeSet)
+func buildChangeSnapshot(list []prometheusCommon.MetricRow) changeSnapshot {
+	ret := make(changeSnapshot, len(list))
+	for _, row := range list {
+		ret[prometheusCommon.LabelSetKey(row.Labels)] = row
+	}
+	return ret
+}
+
+// diffChangeSnapshot compares two snapshots and returns the detected change events
+func diffChangeSnapshot(previous, current changeSnapshot) (events []ChangeEvent) {
+	for key, row := range current {
+		if previousRow, exists := previous[key]; exists {
+			if previousRow.Value != row.Value {
+				events = append(events, ChangeEvent{
+					Type:          ChangeEventChanged,
+					Labels:        row.Labels,
+					Value:         row.Value,
+					PreviousValue: previousRow.Value,
+				})
+			}
+		} else {
+			events = append(events, ChangeEvent{
+				Type:   ChangeEventAdded,
+				Labels: row.Labels,
+				Value:  row.Value,
+			})
+		}
+	}
+
+	for key, previousRow := range previous {
+		if _, exists := current[key]; !exists {
+			events = append(events, ChangeEvent{
+				Type:          ChangeEventRemoved,
+				Labels:        previousRow.Labels,
+				PreviousValue: previousRow.Value,
+			})
+		}
+	}
+
+	return
+}
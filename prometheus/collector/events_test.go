@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+func Test_ChangeSnapshotDiff(t *testing.T) {
+	previous := buildChangeSnapshot([]prometheusCommon.MetricRow{
+		{Labels: prometheus.Labels{"id": "a"}, Value: 1},
+		{Labels: prometheus.Labels{"id": "b"}, Value: 2},
+		{Labels: prometheus.Labels{"id": "c"}, Value: 3},
+	})
+
+	current := buildChangeSnapshot([]prometheusCommon.MetricRow{
+		{Labels: prometheus.Labels{"id": "a"}, Value: 1},
+		{Labels: prometheus.Labels{"id": "b"}, Value: 5},
+		{Labels: prometheus.Labels{"id": "d"}, Value: 4},
+	})
+
+	events := map[string]ChangeEvent{}
+	for _, event := range diffChangeSnapshot(previous, current) {
+		events[event.Labels["id"]] = event
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 change events, got %v", len(events))
+	}
+
+	if event := events["b"]; event.Type != ChangeEventChanged || event.Value != 5 || event.PreviousValue != 2 {
+		t.Errorf("expected changed event for b, got %+v", event)
+	}
+
+	if event := events["c"]; event.Type != ChangeEventRemoved || event.PreviousValue != 3 {
+		t.Errorf("expected removed event for c, got %+v", event)
+	}
+
+	if event := events["d"]; event.Type != ChangeEventAdded || event.Value != 4 {
+		t.Errorf("expected added event for d, got %+v", event)
+	}
+}
@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+type (
+	metricListLimiter interface {
+		LimitSeries(limit int, overflowLabel string) prometheusCommon.SeriesLimitResult
+	}
+)
+
+// processSeriesLimits applies the series limits of all metric lists and exports the series count
+func (c *Collector) processSeriesLimits() {
+	for name, metricList := range c.data.MetricLists {
+		list, ok := metricList.(metricListLimiter)
+		if !ok {
+			continue
+		}
+
+		opts := metricList.getOptions()
+
+		// rows of histograms and summaries are observations which can't be summed up, drop them instead
+		overflowLabel := opts.SeriesOverflowLabel
+		switch metricList.getVec().(type) {
+		case *prometheus.HistogramVec, *prometheus.SummaryVec:
+			overflowLabel = ""
+		}
+
+		result := list.LimitSeries(opts.SeriesLimit, overflowLabel)
+		metricSeries.WithLabelValues(c.Name, name).Set(float64(result.Series))
+
+		if result.Limited == 0 {
+			continue
+		}
+
+		action := "dropped"
+		if overflowLabel != "" {
+			action = "overflow"
+		}
+
+		c.logger.Warn(
+			`metric list reached series limit`,
+			slog.String("list", name),
+			slog.Int("limit", opts.SeriesLimit),
+			slog.Int("series", result.Series),
+			slog.Int("limited", result.Limited),
+			slog.String("action", action),
+		)
+	}
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+func Test_CollectorSeriesLimits(t *testing.T) {
+	c := New("series-limit", &testProcessor{}, slog.New(slog.DiscardHandler))
+	c.SetPrometheusRegistry(prometheus.NewRegistry())
+
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_series_limit"}, []string{"resourceID", "tag_build"})
+	list := c.RegisterMetricList("limited", gauge, true, WithSeriesOverflow(2, "resourceID"))
+	unlimited := c.RegisterMetricList("unlimited", prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_series_unlimited"}, []string{"name"}), true)
+
+	for i := 0; i < 5; i++ {
+		list.Add(prometheus.Labels{"resourceID": fmt.Sprintf("/res%d", i), "tag_build": fmt.Sprintf("%d", i)}, 1)
+	}
+	// rows with the same label set are one series
+	unlimited.Add(prometheus.Labels{"name": "foo"}, 1)
+	unlimited.Add(prometheus.Labels{"name": "foo"}, 1)
+
+	// observations of histograms are dropped instead of summed up
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_series_limit_seconds"}, []string{"resourceID"})
+	observations := c.RegisterMetricList("histogram", histogram, true, WithSeriesOverflow(1, "resourceID"))
+	observations.Add(prometheus.Labels{"resourceID": "/res0"}, 1)
+	observations.Add(prometheus.Labels{"resourceID": "/res1"}, 2)
+
+	c.processSeriesLimits()
+	list.setVec()
+	observations.setVec()
+
+	if count := testutil.CollectAndCount(gauge); count != 3 {
+		t.Errorf("expected 3 series (including overflow), got %v", count)
+	}
+
+	if value := testutil.ToFloat64(gauge.WithLabelValues(prometheusCommon.SeriesOverflowLabelValue, "")); value != 3 {
+		t.Errorf("expected overflow value 3, got %v", value)
+	}
+
+	if value := testutil.ToFloat64(metricSeries.WithLabelValues("series-limit", "limited")); value != 5 {
+		t.Errorf("expected series count 5, got %v", value)
+	}
+
+	if value := testutil.ToFloat64(metricSeries.WithLabelValues("series-limit", "unlimited")); value != 1 {
+		t.Errorf("expected series count 1, got %v", value)
+	}
+
+	if count := testutil.CollectAndCount(histogram); count != 1 {
+		t.Errorf("expected 1 histogram series without overflow, got %v", count)
+	}
+}
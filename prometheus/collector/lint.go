@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+const (
+	LintModeWarn   = "warn"
+	LintModeStrict = "strict"
+)
+
+var (
+	defaultLint = metricLintConfig{}
+)
+
+type (
+	// LintTestingT is the part of testing.TB which is used by AssertMetricLint
+	//
+	//	(the testing package is not imported, so it's not linked into the exporter binaries)
+	LintTestingT interface {
+		Helper()
+		Errorf(format string, args ...interface{})
+	}
+
+	metricLintConfig struct {
+		linter *prometheusCommon.MetricLinter
+		mode   string
+	}
+)
+
+// SetDefaultMetricLinter sets the linter which is used by all new collectors while registering metric lists
+//
+//	LintModeWarn logs the problems, LintModeStrict panics so invalid metrics fail at startup, nil disables the linter
+func SetDefaultMetricLinter(linter *prometheusCommon.MetricLinter, mode string) {
+	defaultLint = newMetricLintConfig(linter, mode)
+}
+
+// SetMetricLinter sets the linter which is used while registering metric lists (see SetDefaultMetricLinter)
+//
+//	processors register their metric lists in Setup (called by New), so SetDefaultMetricLinter has to be used for them
+func (c *Collector) SetMetricLinter(linter *prometheusCommon.MetricLinter, mode string) {
+	c.lint = newMetricLintConfig(linter, mode)
+}
+
+// LintMetricLists checks all registered metric lists with the linter
+func (c *Collector) LintMetricLists(linter *prometheusCommon.MetricLinter) (problems []prometheusCommon.LintProblem) {
+	for _, metricList := range c.data.MetricLists {
+		if collector := metricListPrometheusCollector(metricList); collector != nil {
+			problems = append(problems, linter.LintCollector(collector)...)
+		}
+	}
+	return
+}
+
+// AssertMetricLint fails the test if any registered metric list of the collectors violates the linter rules
+//
+//	func TestMetricNames(t *testing.T) {
+//	  collector.AssertMetricLint(t, prometheusCommon.NewMetricLinter(armclient.AzurePrometheusLabelPrefix), collector.New("resource", &ResourceProcessor{}, logger))
+//	}
+func AssertMetricLint(t LintTestingT, linter *prometheusCommon.MetricLinter, collectors ...*Collector) {
+	t.Helper()
+
+	for _, c := range collectors {
+		for _, problem := range c.LintMetricLists(linter) {
+			t.Errorf(`collector "%v": %v`, c.Name, problem.String())
+		}
+	}
+}
+
+func newMetricLintConfig(linter *prometheusCommon.MetricLinter, mode string) metricLintConfig {
+	if linter == nil {
+		return metricLintConfig{}
+	}
+
+	switch mode {
+	case LintModeWarn, LintModeStrict:
+	default:
+		panic(fmt.Errorf(`unknown lint mode "%s"`, mode))
+	}
+
+	return metricLintConfig{linter: linter, mode: mode}
+}
+
+// lintMetricList checks the metric list while registering, problems are logged or result in a panic (strict mode)
+func (c *Collector) lintMetricList(name string, metricList MetricListInterface) {
+	if c.lint.linter == nil {
+		return
+	}
+
+	collector := metricListPrometheusCollector(metricList)
+	if collector == nil {
+		return
+	}
+
+	problems := c.lint.linter.LintCollector(collector)
+	if len(problems) == 0 {
+		return
+	}
+
+	if c.lint.mode == LintModeStrict {
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = problem.String()
+		}
+		panic(fmt.Errorf(`metric list "%v" violates metric lint rules: %v`, name, strings.Join(messages, "; ")))
+	}
+
+	for _, problem := range problems {
+		c.logger.Warn(
+			`metric list violates metric lint rules`,
+			slog.String("list", name),
+			slog.String("metric", problem.Metric),
+			slog.String("problem", problem.Text),
+		)
+	}
+}
+
+// metricListPrometheusCollector returns the vec (or the list itself if used as prometheus.Collector)
+func metricListPrometheusCollector(metricList MetricListInterface) prometheus.Collector {
+	if list, ok := metricList.(*MetricList); ok && list.IsCollector() {
+		return list.MetricList
+	}
+
+	if collector, ok := metricList.getVec().(prometheus.Collector); ok {
+		return collector
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+func Test_CollectorMetricLint(t *testing.T) {
+	linter := prometheusCommon.NewMetricLinter("tag_")
+
+	c := New("lint", &testProcessor{}, slog.New(slog.DiscardHandler))
+	c.SetPrometheusRegistry(prometheus.NewRegistry())
+	c.SetMetricLinter(linter, LintModeStrict)
+
+	c.RegisterMetricList("valid", prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_lint_info", Help: "Lint test"}, []string{"resourceID"}), true)
+	AssertMetricLint(t, linter, c)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic in strict mode")
+			}
+		}()
+		c.RegisterMetricList("invalid", prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_lint_requests", Help: "Lint test"}, []string{"resource_id"}), true)
+	}()
+
+	if c.GetMetricList("invalid") != nil {
+		t.Error("expected invalid metric list not to be registered")
+	}
+
+	// warn mode only logs
+	c.SetMetricLinter(linter, LintModeWarn)
+	c.RegisterMetricList("warn", prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_lint_warn_total"}, []string{}), true)
+	if problems := c.LintMetricLists(linter); len(problems) != 2 {
+		t.Errorf("expected 2 lint problems, got %v", problems)
+	}
+}
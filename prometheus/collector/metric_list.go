@@ -1,14 +1,153 @@
 package collector
 
 import (
+	"github.com/prometheus/client_golang/prometheus"
+
 	prometheusCommon "github.com/webdevops/go-common/prometheus"
 )
 
 type (
+	// MetricListInterface is implemented by all metric lists managed by the collector
+	//
+	//	the interface contains unexported methods and can't be implemented outside of this package,
+	//	metric lists are created by the Register* functions of the collector
+	MetricListInterface interface {
+		Reset()
+		GetMetricRows() []prometheusCommon.MetricRow
+
+		getVec() interface{}
+		getOptions() *MetricListOptions
+		isResetEnabled() bool
+		setVec()
+		restore(data []byte) error
+	}
+
+	metricListVecSetter interface {
+		GaugeSet(gauge *prometheus.GaugeVec)
+		HistogramSet(histogram *prometheus.HistogramVec)
+		SummarySet(summary *prometheus.SummaryVec)
+		CounterAdd(counter *prometheus.CounterVec)
+	}
+
 	MetricList struct {
 		*prometheusCommon.MetricList
 
-		vec   interface{}
-		reset bool
+		vec     interface{}
+		reset   bool
+		options MetricListOptions
+	}
+
+	HashedMetricList struct {
+		*prometheusCommon.HashedMetricList
+
+		vec     interface{}
+		reset   bool
+		options MetricListOptions
+	}
+
+	TypedMetricList[T any] struct {
+		*prometheusCommon.TypedMetricList[T]
+
+		vec     interface{}
+		reset   bool
+		options MetricListOptions
 	}
 )
+
+// GetMetricRows returns the current metric rows
+func (m *MetricList) GetMetricRows() []prometheusCommon.MetricRow {
+	return m.GetList()
+}
+
+func (m *MetricList) getVec() interface{} {
+	return m.vec
+}
+
+func (m *MetricList) getOptions() *MetricListOptions {
+	return &m.options
+}
+
+func (m *MetricList) isResetEnabled() bool {
+	return m.reset
+}
+
+func (m *MetricList) setVec() {
+	if m.IsCollector() {
+		m.Publish()
+		return
+	}
+
+	setMetricListToVec(m, m.vec)
+}
+
+func (m *MetricList) restore(data []byte) error {
+	return m.UnmarshalJSON(data)
+}
+
+func (m *HashedMetricList) getVec() interface{} {
+	return m.vec
+}
+
+func (m *HashedMetricList) getOptions() *MetricListOptions {
+	return &m.options
+}
+
+func (m *HashedMetricList) isResetEnabled() bool {
+	return m.reset
+}
+
+func (m *HashedMetricList) setVec() {
+	setMetricListToVec(m, m.vec)
+}
+
+func (m *HashedMetricList) restore(data []byte) error {
+	return m.UnmarshalJSON(data)
+}
+
+func (m *TypedMetricList[T]) getVec() interface{} {
+	return m.vec
+}
+
+func (m *TypedMetricList[T]) getOptions() *MetricListOptions {
+	return &m.options
+}
+
+func (m *TypedMetricList[T]) isResetEnabled() bool {
+	return m.reset
+}
+
+func (m *TypedMetricList[T]) setVec() {
+	setMetricListToVec(m, m.vec)
+}
+
+func (m *TypedMetricList[T]) restore(data []byte) error {
+	return m.UnmarshalJSON(data)
+}
+
+// setMetricListToVec passes metrics from list to the prometheus vec
+func setMetricListToVec(list metricListVecSetter, vec interface{}) {
+	switch vec := vec.(type) {
+	case *prometheus.GaugeVec:
+		list.GaugeSet(vec)
+	case *prometheus.HistogramVec:
+		list.HistogramSet(vec)
+	case *prometheus.SummaryVec:
+		list.SummarySet(vec)
+	case *prometheus.CounterVec:
+		list.CounterAdd(vec)
+	}
+}
+
+// resetVec resets the prometheus vec
+func resetVec(vec interface{}) {
+	switch vec := vec.(type) {
+	case *prometheus.GaugeVec:
+		vec.Reset()
+	case *prometheus.HistogramVec:
+		vec.Reset()
+	case *prometheus.SummaryVec:
+		vec.Reset()
+	case *prometheus.CounterVec:
+		vec.Reset()
+	}
+}
@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"time"
+)
+
+type (
+	// MetricListOptions are the options of a metric list managed by the collector
+	MetricListOptions struct {
+		// maximum number of series per run (0 = unlimited)
+		SeriesLimit int
+
+		// label which marks the overflow series, series beyond the limit are dropped if empty
+		SeriesOverflowLabel string
+
+		// series which are not part of the list within the TTL are removed from the vec (only without reset)
+		SeriesTTL time.Duration
+	}
+
+	MetricListOptionFunc func(*MetricListOptions)
+)
+
+// WithSeriesLimit limits the number of series of the metric list, series beyond the limit are dropped
+func WithSeriesLimit(limit int) MetricListOptionFunc {
+	return func(opts *MetricListOptions) {
+		opts.SeriesLimit = limit
+		opts.SeriesOverflowLabel = ""
+	}
+}
+
+// WithSeriesOverflow limits the number of series of the metric list, series beyond the limit are summed up
+// into an overflow series where all label values are empty and the overflowLabel is set to "__overflow__"
+//
+//	overflowLabel should be a label of the metric (eg. resourceID) so the overflow series fits into the vec,
+//	series of histogram and summary vecs are dropped as observations can't be summed up
+func WithSeriesOverflow(limit int, overflowLabel string) MetricListOptionFunc {
+	return func(opts *MetricListOptions) {
+		opts.SeriesLimit = limit
+		opts.SeriesOverflowLabel = overflowLabel
+	}
+}
+
+// WithSeriesTTL removes series from the vec which were not part of the metric list within the ttl
+//
+//	only used for metric lists without reset (eg. counters), lists with reset are cleared on each run
+func WithSeriesTTL(ttl time.Duration) MetricListOptionFunc {
+	return func(opts *MetricListOptions) {
+		opts.SeriesTTL = ttl
+	}
+}
+
+// newMetricListOptions applies the option funcs
+func newMetricListOptions(opts ...MetricListOptionFunc) MetricListOptions {
+	ret := MetricListOptions{}
+	for _, opt := range opts {
+		opt(&ret)
+	}
+	return ret
+}
@@ -54,6 +54,63 @@ var (
 			"collector",
 		},
 	)
+
+	metricChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "collector_metric_changes_total",
+			Help: "Collector metric list series changes between runs",
+		},
+		[]string{
+			"collector",
+			"list",
+			"type",
+		},
+	)
+
+	metricDuplicates = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "collector_metric_duplicates_total",
+			Help: "Collector metric list duplicate series",
+		},
+		[]string{
+			"collector",
+			"list",
+		},
+	)
+
+	metricErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "collector_errors_total",
+			Help: "Collector errors by kind",
+		},
+		[]string{
+			"collector",
+			"kind",
+		},
+	)
+
+	metricLastErrorInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "collector_last_error_info",
+			Help: "Collector last error (until the next successful run)",
+		},
+		[]string{
+			"collector",
+			"kind",
+			"message",
+		},
+	)
+
+	metricSeries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "collector_metric_series",
+			Help: "Collector metric list series count of last run",
+		},
+		[]string{
+			"collector",
+			"list",
+		},
+	)
 )
 
 func init() {
@@ -63,5 +120,10 @@ func init() {
 		metricDuration,
 		metricSuccess,
 		metricLastCollect,
+		metricChanges,
+		metricDuplicates,
+		metricSeries,
+		metricErrors,
+		metricLastErrorInfo,
 	)
 }
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+type (
+	// seriesExpiry tracks when the series of a metric list were seen the last time
+	seriesExpiry map[string]seriesExpiryEntry
+
+	seriesExpiryEntry struct {
+		labels   prometheus.Labels
+		lastSeen time.Time
+	}
+)
+
+// processSeriesTTL deletes series from the vecs which were not part of the metric lists within the series TTL
+//
+//	only used for lists without reset, lists with reset are cleared on each run anyway
+func (c *Collector) processSeriesTTL() {
+	now := time.Now()
+
+	for name, metricList := range c.data.MetricLists {
+		ttl := metricList.getOptions().SeriesTTL
+		if ttl <= 0 || metricList.isResetEnabled() {
+			continue
+		}
+
+		if c.seriesExpiry == nil {
+			c.seriesExpiry = map[string]seriesExpiry{}
+		}
+
+		expiry, exists := c.seriesExpiry[name]
+		if !exists {
+			expiry = seriesExpiry{}
+			c.seriesExpiry[name] = expiry
+		}
+
+		for _, row := range metricList.GetMetricRows() {
+			expiry[prometheusCommon.LabelSetKey(row.Labels)] = seriesExpiryEntry{labels: row.Labels, lastSeen: now}
+		}
+
+		for key, entry := range expiry {
+			if now.Sub(entry.lastSeen) > ttl {
+				deleteVecSeries(metricList.getVec(), entry.labels)
+				delete(expiry, key)
+			}
+		}
+	}
+}
+
+// deleteVecSeries deletes the series with the labels from the prometheus vec
+func deleteVecSeries(vec interface{}, labels prometheus.Labels) {
+	switch vec := vec.(type) {
+	case *prometheus.GaugeVec:
+		vec.Delete(labels)
+	case *prometheus.HistogramVec:
+		vec.Delete(labels)
+	case *prometheus.SummaryVec:
+		vec.Delete(labels)
+	case *prometheus.CounterVec:
+		vec.Delete(labels)
+	}
+}
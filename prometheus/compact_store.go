@@ -0,0 +1,146 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// compactMetricStore stores metric rows with interned label strings
+	//
+	//	label names and values are deduplicated in a string table and label sets are stored
+	//	as (name, value) index pairs in one shared slice instead of one map per row
+	compactMetricStore struct {
+		strings    map[string]uint32
+		stringList []string
+
+		labelData []uint32
+		rows      []compactMetricRow
+	}
+
+	compactMetricRow struct {
+		labels compactLabelRef
+
+		// row without labels
+		row MetricRow
+	}
+
+	compactLabelRef struct {
+		offset uint32
+		count  uint32
+	}
+)
+
+func newCompactMetricStore() *compactMetricStore {
+	return &compactMetricStore{
+		strings: map[string]uint32{},
+	}
+}
+
+// intern returns the index of the string in the string table
+func (s *compactMetricStore) intern(val string) uint32 {
+	if idx, exists := s.strings[val]; exists {
+		return idx
+	}
+
+	idx := uint32(len(s.stringList)) // #nosec G115 string table will not exceed uint32
+	s.stringList = append(s.stringList, val)
+	s.strings[val] = idx
+	return idx
+}
+
+// addLabels stores the label set and returns the reference
+func (s *compactMetricStore) addLabels(labels prometheus.Labels) compactLabelRef {
+	ref := compactLabelRef{
+		offset: uint32(len(s.labelData)), // #nosec G115 label data will not exceed uint32
+		count:  uint32(len(labels)),      // #nosec G115 label count will not exceed uint32
+	}
+
+	for name, value := range labels {
+		s.labelData = append(s.labelData, s.intern(name), s.intern(value))
+	}
+
+	return ref
+}
+
+// fillLabelRef clears the passed label map and fills it with the referenced label set
+func (s *compactMetricStore) fillLabelRef(ref compactLabelRef, labels prometheus.Labels) {
+	clear(labels)
+
+	labelData := s.labelData[ref.offset : ref.offset+ref.count*2]
+	for i := 0; i < len(labelData); i += 2 {
+		labels[s.stringList[labelData[i]]] = s.stringList[labelData[i+1]]
+	}
+}
+
+// equalLabels checks if the referenced label set equals the passed labels (without allocations)
+func (s *compactMetricStore) equalLabels(ref compactLabelRef, labels prometheus.Labels) bool {
+	if int(ref.count) != len(labels) {
+		return false
+	}
+
+	labelData := s.labelData[ref.offset : ref.offset+ref.count*2]
+	for i := 0; i < len(labelData); i += 2 {
+		if value, exists := labels[s.stringList[labelData[i]]]; !exists || value != s.stringList[labelData[i+1]] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// add adds the row and returns the row index
+func (s *compactMetricStore) add(row MetricRow) int {
+	compactRow := compactMetricRow{
+		labels: s.addLabels(row.Labels),
+	}
+
+	row.Labels = nil
+	compactRow.row = row
+	s.rows = append(s.rows, compactRow)
+
+	return len(s.rows) - 1
+}
+
+// len returns the number of rows
+func (s *compactMetricStore) len() int {
+	return len(s.rows)
+}
+
+// value returns a pointer to the row (without labels) for in-place updates
+func (s *compactMetricStore) value(idx int) *MetricRow {
+	return &s.rows[idx].row
+}
+
+// fillLabels clears the passed label map and fills it with the labels of the row
+func (s *compactMetricStore) fillLabels(idx int, labels prometheus.Labels) {
+	s.fillLabelRef(s.rows[idx].labels, labels)
+}
+
+// forEach calls the callback for every row, the passed label map is reused and only valid during the callback
+func (s *compactMetricStore) forEach(callback func(row MetricRow)) {
+	labels := prometheus.Labels{}
+	for idx := range s.rows {
+		s.fillLabels(idx, labels)
+		row := s.rows[idx].row
+		row.Labels = labels
+		callback(row)
+	}
+}
+
+// row returns the row with its own label map
+func (s *compactMetricStore) row(idx int) MetricRow {
+	labels := make(prometheus.Labels, s.rows[idx].labels.count)
+	s.fillLabels(idx, labels)
+	row := s.rows[idx].row
+	row.Labels = labels
+	return row
+}
+
+// list returns all rows with their own label maps
+func (s *compactMetricStore) list() []MetricRow {
+	list := make([]MetricRow, 0, len(s.rows))
+	for idx := range s.rows {
+		list = append(list, s.row(idx))
+	}
+	return list
+}
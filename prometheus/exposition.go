@@ -0,0 +1,346 @@
+package prometheus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	MetricFamilyTypeCounter   = "counter"
+	MetricFamilyTypeGauge     = "gauge"
+	MetricFamilyTypeUntyped   = "untyped"
+	MetricFamilyTypeSummary   = "summary"
+	MetricFamilyTypeHistogram = "histogram"
+
+	ExpositionAcceptHeader = `application/openmetrics-text;version=1.0.0;q=0.9,text/plain;version=0.0.4;q=0.5,*/*;q=0.1`
+
+	openMetricsContentType = "application/openmetrics-text"
+	openMetricsEOF         = "# EOF"
+)
+
+type (
+	// MetricFamily is a parsed metric family of the Prometheus exposition format
+	//
+	//	summaries and histograms are split into multiple families (eg. <name>_bucket, <name>_sum, <name>_count)
+	//	which keep the type of the original family
+	MetricFamily struct {
+		Name string
+		Help string
+		Type string
+		List *MetricList
+	}
+)
+
+// MetricDesc returns the MetricDesc (see MetricList.SetDesc) of the family
+//
+//	label names are taken from the current rows, so it should be called after the list was transformed
+func (f *MetricFamily) MetricDesc() MetricDesc {
+	labelNames := map[string]bool{}
+	f.List.forEach(func(row MetricRow) {
+		for labelName := range row.Labels {
+			labelNames[labelName] = true
+		}
+	})
+
+	valueType := prometheus.UntypedValue
+	switch f.Type {
+	case MetricFamilyTypeCounter:
+		valueType = prometheus.CounterValue
+	case MetricFamilyTypeGauge:
+		valueType = prometheus.GaugeValue
+	}
+
+	return MetricDesc{
+		Name:       f.Name,
+		Help:       f.Help,
+		LabelNames: slices.Sorted(maps.Keys(labelNames)),
+		ValueType:  valueType,
+	}
+}
+
+// ScrapeExposition fetches the url and parses the Prometheus text or OpenMetrics response (see ParseExposition)
+func ScrapeExposition(ctx context.Context, client *http.Client, url string) (map[string]*MetricFamily, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ExpositionAcceptHeader)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to scrape "%v": %w`, url, err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf(`unable to scrape "%v": unexpected status code %v`, url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to scrape "%v": %w`, url, err)
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && mediaType == openMetricsContentType {
+		return parseExposition(data, true)
+	}
+
+	return parseExposition(data, isOpenMetrics(data))
+}
+
+// ParseExposition parses the Prometheus text or OpenMetrics format into one MetricList per metric family
+//
+//	OpenMetrics is detected by the "# EOF" marker, exemplars and created series of OpenMetrics are dropped
+func ParseExposition(r io.Reader) (map[string]*MetricFamily, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseExposition(data, isOpenMetrics(data))
+}
+
+func parseExposition(data []byte, openMetrics bool) (map[string]*MetricFamily, error) {
+	if openMetrics {
+		data = convertOpenMetricsToText(data)
+	}
+
+	parser := expfmt.NewTextParser(model.LegacyValidation)
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf(`unable to parse exposition format: %w`, err)
+	}
+
+	ret := map[string]*MetricFamily{}
+	for _, family := range families {
+		addMetricFamily(ret, family)
+	}
+
+	return ret, nil
+}
+
+// addMetricFamily converts the dto metric family into metric lists
+func addMetricFamily(families map[string]*MetricFamily, family *dto.MetricFamily) {
+	familyType := strings.ToLower(family.GetType().String())
+
+	add := func(name string, metric *dto.Metric, labels prometheus.Labels, value float64) {
+		if _, exists := families[name]; !exists {
+			families[name] = &MetricFamily{
+				Name: name,
+				Help: family.GetHelp(),
+				Type: familyType,
+				List: NewMetricsList(),
+			}
+		}
+
+		row := MetricRow{Labels: labels, Value: value}
+		if metric.TimestampMs != nil {
+			timestamp := time.UnixMilli(metric.GetTimestampMs())
+			row.Timestamp = &timestamp
+		}
+		families[name].List.AddRow(row)
+	}
+
+	name := family.GetName()
+	for _, metric := range family.GetMetric() {
+		labels := prometheus.Labels{}
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			add(name, metric, labels, metric.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			add(name, metric, labels, metric.GetGauge().GetValue())
+		case dto.MetricType_SUMMARY:
+			summary := metric.GetSummary()
+			for _, quantile := range summary.GetQuantile() {
+				add(name, metric, metricFamilyLabels(labels, model.QuantileLabel, formatExpositionFloat(quantile.GetQuantile())), quantile.GetValue())
+			}
+			add(name+"_sum", metric, maps.Clone(labels), summary.GetSampleSum())
+			add(name+"_count", metric, maps.Clone(labels), float64(summary.GetSampleCount()))
+		case dto.MetricType_HISTOGRAM:
+			histogram := metric.GetHistogram()
+			for _, bucket := range histogram.GetBucket() {
+				add(name+"_bucket", metric, metricFamilyLabels(labels, model.BucketLabel, formatExpositionFloat(bucket.GetUpperBound())), float64(bucket.GetCumulativeCount()))
+			}
+			add(name+"_sum", metric, maps.Clone(labels), histogram.GetSampleSum())
+			add(name+"_count", metric, maps.Clone(labels), float64(histogram.GetSampleCount()))
+		default:
+			add(name, metric, labels, metric.GetUntyped().GetValue())
+		}
+	}
+}
+
+// metricFamilyLabels copies the labels and adds the label (eg. quantile or le)
+func metricFamilyLabels(labels prometheus.Labels, name, value string) prometheus.Labels {
+	ret := maps.Clone(labels)
+	ret[name] = value
+	return ret
+}
+
+func formatExpositionFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// isOpenMetrics checks if the data ends with the OpenMetrics EOF marker
+func isOpenMetrics(data []byte) bool {
+	return bytes.HasSuffix(bytes.TrimSpace(data), []byte(openMetricsEOF))
+}
+
+// convertOpenMetricsToText rewrites OpenMetrics to the Prometheus text format
+//
+//	counter families are named with _total suffix, OpenMetrics only types are mapped to gauge/histogram/untyped
+//	(_gcount and _gsum samples of gaugehistograms are renamed to _count and _sum),
+//	exemplars, created series and unit comments are dropped and timestamps are converted from seconds to milliseconds
+func convertOpenMetricsToText(data []byte) []byte {
+	familyTypes := map[string]string{}
+	for line := range strings.Lines(string(data)) {
+		if fields := strings.Fields(line); len(fields) >= 4 && fields[0] == "#" && fields[1] == "TYPE" {
+			familyTypes[fields[2]] = fields[3]
+		}
+	}
+
+	// familyName returns the text format name and type of an OpenMetrics family
+	familyName := func(name string) (string, string) {
+		switch familyTypes[name] {
+		case "counter":
+			if strings.HasSuffix(name, "_total") {
+				return name, MetricFamilyTypeCounter
+			}
+			return name + "_total", MetricFamilyTypeCounter
+		case "info":
+			return name + "_info", MetricFamilyTypeGauge
+		case "stateset":
+			return name, MetricFamilyTypeGauge
+		case "gaugehistogram":
+			return name, MetricFamilyTypeHistogram
+		case MetricFamilyTypeGauge, MetricFamilyTypeSummary, MetricFamilyTypeHistogram:
+			return name, familyTypes[name]
+		}
+		return name, MetricFamilyTypeUntyped
+	}
+
+	ret := bytes.Buffer{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(line, " ", 4)
+			if len(fields) < 3 {
+				continue
+			}
+
+			switch fields[1] {
+			case "TYPE":
+				name, familyType := familyName(fields[2])
+				fmt.Fprintf(&ret, "# TYPE %s %s\n", name, familyType)
+			case "HELP":
+				name, _ := familyName(fields[2])
+				help := ""
+				if len(fields) == 4 {
+					help = fields[3]
+				}
+				fmt.Fprintf(&ret, "# HELP %s %s\n", name, help)
+			}
+			continue
+		}
+
+		if line = convertOpenMetricsSample(line, familyTypes); line != "" {
+			ret.WriteString(line)
+			ret.WriteByte('\n')
+		}
+	}
+
+	return ret.Bytes()
+}
+
+// convertOpenMetricsSample removes exemplar and created series and converts the timestamp of a sample line
+//
+//	_gcount and _gsum samples of gaugehistograms are renamed to _count and _sum of the histogram
+func convertOpenMetricsSample(line string, familyTypes map[string]string) string {
+	end := openMetricsSeriesEnd(line)
+	if end == -1 {
+		return ""
+	}
+	name, _, _ := strings.Cut(line[:end], "{")
+
+	if baseName, found := strings.CutSuffix(name, "_created"); found {
+		switch familyTypes[baseName] {
+		case "counter", MetricFamilyTypeSummary, MetricFamilyTypeHistogram, "gaugehistogram":
+			return ""
+		}
+	}
+
+	for suffix, histogramSuffix := range map[string]string{"_gcount": "_count", "_gsum": "_sum"} {
+		if baseName, found := strings.CutSuffix(name, suffix); found && familyTypes[baseName] == "gaugehistogram" {
+			line = baseName + histogramSuffix + line[len(name):]
+			end += len(histogramSuffix) - len(suffix)
+		}
+	}
+
+	sample, _, _ := strings.Cut(line[end:], "#")
+	fields := strings.Fields(sample)
+	switch len(fields) {
+	case 1:
+		return line[:end] + " " + fields[0]
+	case 2:
+		timestamp, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return line[:end] + " " + fields[0]
+		}
+		return line[:end] + " " + fields[0] + " " + strconv.FormatInt(int64(math.Round(timestamp*1000)), 10)
+	}
+
+	return line[:end]
+}
+
+// openMetricsSeriesEnd returns the end of the metric name and labels of a sample line (-1 if not found)
+//
+//	label values can contain spaces and "#", so quotes have to be respected
+func openMetricsSeriesEnd(line string) int {
+	end := strings.IndexAny(line, " {")
+	if end == -1 || line[end] == ' ' {
+		return end
+	}
+
+	inQuotes := false
+	for end++; end < len(line); end++ {
+		switch {
+		case line[end] == '\\' && inQuotes:
+			end++
+		case line[end] == '"':
+			inQuotes = !inQuotes
+		case line[end] == '}' && !inQuotes:
+			return end + 1
+		}
+	}
+
+	return -1
+}
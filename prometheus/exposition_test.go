@@ -0,0 +1,178 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const (
+	testExpositionText = `# HELP sidecar_requests_total Handled requests
+# TYPE sidecar_requests_total counter
+sidecar_requests_total{code="200",resourceID="/foo"} 12
+sidecar_requests_total{code="500",resourceID="/foo"} 3 1700000000000
+# HELP sidecar_queue_length Current queue length
+# TYPE sidecar_queue_length gauge
+sidecar_queue_length 7
+# TYPE sidecar_latency_seconds histogram
+sidecar_latency_seconds_bucket{le="0.1"} 1
+sidecar_latency_seconds_bucket{le="+Inf"} 2
+sidecar_latency_seconds_sum 1.5
+sidecar_latency_seconds_count 2
+`
+
+	testExpositionOpenMetrics = `# TYPE sidecar_requests counter
+# HELP sidecar_requests Handled requests
+sidecar_requests_total{code="200",path="/a # {b}"} 12 # {trace_id="abc"} 1 1700000000.5
+sidecar_requests_created{code="200",path="/a # {b}"} 1600000000
+sidecar_requests_total{code="500",path="/"} 3 1700000000.5
+# TYPE sidecar_build info
+sidecar_build_info{version="1.0"} 1
+# UNIT sidecar_build seconds
+# TYPE sidecar_queue_wait_seconds gaugehistogram
+sidecar_queue_wait_seconds_bucket{le="1"} 2
+sidecar_queue_wait_seconds_bucket{le="+Inf"} 3
+sidecar_queue_wait_seconds_gcount 3
+sidecar_queue_wait_seconds_gsum 2.5
+# EOF
+`
+)
+
+func Test_ParseExposition(t *testing.T) {
+	families, err := ParseExposition(strings.NewReader(testExpositionText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(families) != 5 {
+		t.Fatalf("expected 5 families, got %v", len(families))
+	}
+
+	requests := families["sidecar_requests_total"]
+	if requests.Type != MetricFamilyTypeCounter || requests.Help != "Handled requests" {
+		t.Errorf("unexpected type/help: %v / %v", requests.Type, requests.Help)
+	}
+
+	for _, row := range requests.List.GetList() {
+		switch row.Labels["code"] {
+		case "200":
+			if row.Value != 12 || row.Timestamp != nil {
+				t.Errorf("unexpected row: %v", row)
+			}
+		case "500":
+			if row.Value != 3 || row.Timestamp == nil || row.Timestamp.UnixMilli() != 1700000000000 {
+				t.Errorf("unexpected row: %v", row)
+			}
+		}
+	}
+
+	buckets := families["sidecar_latency_seconds_bucket"]
+	if buckets.Type != MetricFamilyTypeHistogram || len(buckets.List.GetList()) != 2 {
+		t.Errorf("unexpected bucket family: %v", buckets)
+	}
+	if families["sidecar_latency_seconds_count"].List.GetList()[0].Value != 2 {
+		t.Error("unexpected histogram count")
+	}
+}
+
+func Test_ParseExpositionOpenMetrics(t *testing.T) {
+	families, err := ParseExposition(strings.NewReader(testExpositionOpenMetrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(families) != 5 {
+		t.Fatalf("expected 5 families, got %v", len(families))
+	}
+
+	requests := families["sidecar_requests_total"]
+	if requests.Type != MetricFamilyTypeCounter || requests.Help != "Handled requests" {
+		t.Errorf("unexpected type/help: %v / %v", requests.Type, requests.Help)
+	}
+
+	for _, row := range requests.List.GetList() {
+		switch row.Labels["code"] {
+		case "200":
+			if row.Value != 12 || row.Labels["path"] != "/a # {b}" || row.Timestamp != nil {
+				t.Errorf("unexpected row: %v", row)
+			}
+		case "500":
+			if row.Value != 3 || row.Timestamp == nil || row.Timestamp.UnixMilli() != 1700000000500 {
+				t.Errorf("unexpected row: %v", row)
+			}
+		}
+	}
+
+	if info := families["sidecar_build_info"]; info.Type != MetricFamilyTypeGauge {
+		t.Errorf("expected info family as gauge, got %v", info.Type)
+	}
+
+	// gaugehistogram _gcount/_gsum samples are mapped to _count/_sum of the histogram
+	if buckets := families["sidecar_queue_wait_seconds_bucket"]; buckets.Type != MetricFamilyTypeHistogram || len(buckets.List.GetList()) != 2 {
+		t.Errorf("unexpected gaugehistogram bucket family: %v", buckets)
+	}
+	if count := families["sidecar_queue_wait_seconds_count"]; count == nil || count.List.GetList()[0].Value != 3 {
+		t.Errorf("unexpected gaugehistogram count family: %v", count)
+	}
+	if sum := families["sidecar_queue_wait_seconds_sum"]; sum == nil || sum.List.GetList()[0].Value != 2.5 {
+		t.Errorf("unexpected gaugehistogram sum family: %v", sum)
+	}
+}
+
+func Test_ScrapeExposition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte(testExpositionText)) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	families, err := ScrapeExposition(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// enrich and re-export
+	requests := families["sidecar_requests_total"]
+	enriched, err := requests.List.Relabel(RelabelConfig{
+		SourceLabels: []string{"resourceID"},
+		TargetLabel:  "tag_owner",
+		Replacement:  func(s string) *string { return &s }("team-a"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests.List = enriched
+	requests.List.SetDesc(requests.MetricDesc())
+	requests.List.Publish()
+
+	expected := `
+# HELP sidecar_requests_total Handled requests
+# TYPE sidecar_requests_total counter
+sidecar_requests_total{code="200",resourceID="/foo",tag_owner="team-a"} 12
+sidecar_requests_total{code="500",resourceID="/foo",tag_owner="team-a"} 3 1700000000000
+`
+	if err := testutil.CollectAndCompare(requests.List, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(requests.List); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ScrapeExpositionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := ScrapeExposition(context.Background(), server.Client(), server.URL); err == nil {
+		t.Error("expected error for failed scrape")
+	}
+}
@@ -0,0 +1,92 @@
+package golden
+
+import (
+	"strings"
+)
+
+const (
+	// maximum number of cells of the LCS matrix, larger changes are rendered as removed and added block
+	diffMaxMatrixSize = 1 << 22
+)
+
+// Diff returns a line based diff of expected and actual ("-" expected only, "+" actual only)
+//
+//	empty if both are equal
+//
+//	common leading and trailing lines are trimmed before the lines in between are aligned,
+//	if the changed block is too large to be aligned it's rendered as removed and added lines
+func Diff(expected, actual string) string {
+	if expected == actual {
+		return ""
+	}
+
+	a := strings.Split(strings.TrimSuffix(expected, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(actual, "\n"), "\n")
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	diff := strings.Builder{}
+	for _, line := range a[:prefix] {
+		diff.WriteString("  " + line + "\n")
+	}
+
+	changedA := a[prefix : len(a)-suffix]
+	changedB := b[prefix : len(b)-suffix]
+	if (len(changedA)+1)*(len(changedB)+1) > diffMaxMatrixSize {
+		for _, line := range changedA {
+			diff.WriteString("- " + line + "\n")
+		}
+		for _, line := range changedB {
+			diff.WriteString("+ " + line + "\n")
+		}
+	} else {
+		diffLines(&diff, changedA, changedB)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		diff.WriteString("  " + line + "\n")
+	}
+
+	return diff.String()
+}
+
+// diffLines writes the diff of the lines aligned by the longest common subsequence
+func diffLines(diff *strings.Builder, a, b []string) {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+}
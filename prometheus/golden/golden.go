@@ -0,0 +1,61 @@
+package golden
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	UpdateFlag   = "update"
+	UpdateEnvVar = "UPDATE_GOLDEN"
+)
+
+// IsUpdate returns true if golden files should be rewritten (UPDATE_GOLDEN=1)
+//
+//	the flag is not registered by this package (would conflict with test packages defining their own -update flag),
+//	an -update flag defined by the test package is also used
+func IsUpdate() bool {
+	if updateFlag := flag.Lookup(UpdateFlag); updateFlag != nil && updateFlag.Value.String() == "true" {
+		return true
+	}
+
+	switch os.Getenv(UpdateEnvVar) {
+	case "1", "true":
+		return true
+	}
+
+	return false
+}
+
+// Assert compares the actual output with the golden file and fails the test with a diff if they are different
+//
+//	with update flag the golden file is rewritten instead
+//
+//	golden.Assert(t, "testdata/resource.golden", golden.RenderMetricList("azurerm_resource_info", list))
+func Assert(t testing.TB, path string, actual string) {
+	t.Helper()
+
+	if IsUpdate() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		/* #nosec G306 */
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	/*  #nosec G304 */
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read golden file (run tests with %v=1 to create it): %v", UpdateEnvVar, err)
+	}
+
+	if diff := Diff(string(expected), actual); diff != "" {
+		t.Errorf("output does not match golden file %v (run tests with %v=1 to update it):\n%v", path, UpdateEnvVar, diff)
+	}
+}
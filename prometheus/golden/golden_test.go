@@ -0,0 +1,124 @@
+package golden
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+	"github.com/webdevops/go-common/prometheus/kusto"
+)
+
+// test packages often define their own -update flag, must not conflict with the golden package
+var update = flag.Bool(UpdateFlag, false, "update golden files")
+
+type (
+	recordingT struct {
+		testing.TB
+		errors []string
+	}
+)
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func Test_GoldenMetricList(t *testing.T) {
+	list := prometheusCommon.NewMetricsList()
+	list.Add(prometheus.Labels{"resourceID": "/foo", "location": "westeurope"}, 1)
+	list.AddWithTimestamp(prometheus.Labels{"resourceID": "/bar", "location": "northeurope"}, 2.5, time.UnixMilli(1700000000000))
+	list.Add(prometheus.Labels{"resourceID": "/baz", "location": "west\"europe"}, math.Inf(1))
+
+	Assert(t, "testdata/metric_list.golden", RenderMetricList("azurerm_resource_info", list))
+}
+
+func Test_GoldenHashedMetricList(t *testing.T) {
+	list := prometheusCommon.NewHashedMetricsList()
+	for i := 0; i < 3; i++ {
+		list.Inc(prometheus.Labels{"resourceID": fmt.Sprintf("/res%d", i%2)})
+	}
+
+	Assert(t, "testdata/hashed_metric_list.golden", RenderHashedMetricList("azurerm_resource_count", list))
+}
+
+func Test_GoldenKustoMetricList(t *testing.T) {
+	value := float64(12)
+	list := kusto.MetricList{}
+	list.Init()
+	list.Add("azure_testing", kusto.MetricRow{Labels: prometheus.Labels{"id": "foo"}, Value: &value})
+	list.Add("azure_testing_value", kusto.MetricRow{Labels: prometheus.Labels{"id": "foo", "scope": "one"}, Value: &value})
+	list.Add("azure_testing_value", kusto.MetricRow{Labels: prometheus.Labels{"id": "bar"}})
+
+	Assert(t, "testdata/kusto_metric_list.golden", RenderKustoMetricList(&list))
+}
+
+func Test_GoldenMismatch(t *testing.T) {
+	if IsUpdate() {
+		t.Skip("golden files are updated")
+	}
+
+	recorder := &recordingT{TB: t}
+	Assert(recorder, "testdata/hashed_metric_list.golden", "azurerm_resource_count{resourceID=\"/res0\"} 2\nazurerm_resource_count{resourceID=\"/res2\"} 1\n")
+	if len(recorder.errors) != 1 || !strings.Contains(recorder.errors[0], "- azurerm_resource_count{resourceID=\"/res1\"} 1\n+ azurerm_resource_count{resourceID=\"/res2\"} 1") {
+		t.Errorf("expected diff, got %v", recorder.errors)
+	}
+}
+
+func Test_Diff(t *testing.T) {
+	if diff := Diff("a\nb\n", "a\nb\n"); diff != "" {
+		t.Errorf("expected no diff, got %v", diff)
+	}
+
+	expected := "  a\n- b\n+ c\n  d\n"
+	if diff := Diff("a\nb\nd\n", "a\nc\nd\n"); diff != expected {
+		t.Errorf("expected diff %q, got %q", expected, diff)
+	}
+}
+
+func Test_DiffLarge(t *testing.T) {
+	lines := make([]string, 0, 10000)
+	for i := 0; i < cap(lines); i++ {
+		lines = append(lines, fmt.Sprintf("line%d", i))
+	}
+	expected := strings.Join(lines, "\n") + "\n"
+
+	// changed block is too large to be aligned, common lines are still trimmed
+	changed := slices.Clone(lines)
+	for i := 1; i < len(changed)-1; i++ {
+		changed[i] += "x"
+	}
+	diff := Diff(expected, strings.Join(changed, "\n")+"\n")
+	if !strings.HasPrefix(diff, "  line0\n- line1\n- line2\n") || !strings.HasSuffix(diff, "+ line9998x\n  line9999\n") {
+		t.Errorf("unexpected diff of large change")
+	}
+}
+
+func Test_RenderLabelEscaping(t *testing.T) {
+	expected := "test_metric{name=\"a\\\\b\\\"c\\nd\te\u00fc\"} 1\n"
+	if rendered := RenderMetricRows("test_metric", []prometheusCommon.MetricRow{{Labels: prometheus.Labels{"name": "a\\b\"c\nd\te\u00fc"}, Value: 1}}); rendered != expected {
+		t.Errorf("expected %q, got %q", expected, rendered)
+	}
+}
+
+func Test_IsUpdateFlag(t *testing.T) {
+	if IsUpdate() {
+		t.Skip("golden files are updated")
+	}
+
+	if err := flag.Set(UpdateFlag, "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer flag.Set(UpdateFlag, "false") // nolint: errcheck
+
+	if !*update || !IsUpdate() {
+		t.Error("expected update mode from flag defined by test package")
+	}
+}
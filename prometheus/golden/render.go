@@ -0,0 +1,117 @@
+package golden
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+	"github.com/webdevops/go-common/prometheus/kusto"
+)
+
+var (
+	// escapes label values like the text exposition format
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// RenderMetricList renders the metric list as sorted exposition text
+func RenderMetricList(name string, list *prometheusCommon.MetricList) string {
+	return RenderMetricRows(name, list.GetList())
+}
+
+// RenderHashedMetricList renders the hashed metric list as sorted exposition text
+func RenderHashedMetricList(name string, list *prometheusCommon.HashedMetricList) string {
+	return RenderMetricRows(name, list.GetList())
+}
+
+// RenderMetricRows renders the metric rows as sorted exposition text
+func RenderMetricRows(name string, rows []prometheusCommon.MetricRow) string {
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		var timestamp *int64
+		if row.Timestamp != nil {
+			timestamp = new(int64)
+			*timestamp = row.Timestamp.UnixMilli()
+		}
+		lines = append(lines, renderLine(name, row.Labels, row.Value, timestamp))
+	}
+
+	return joinLines(lines)
+}
+
+// RenderKustoMetricList renders all metrics of the kusto metric list as sorted exposition text
+//
+//	rows without value are skipped as they are not exported
+func RenderKustoMetricList(list *kusto.MetricList) string {
+	lines := []string{}
+	for name, rows := range list.List {
+		for _, row := range rows {
+			if row.Value == nil {
+				continue
+			}
+
+			var timestamp *int64
+			if row.Timestamp != nil {
+				timestamp = new(int64)
+				*timestamp = row.Timestamp.UnixMilli()
+			}
+			lines = append(lines, renderLine(name, row.Labels, *row.Value, timestamp))
+		}
+	}
+
+	return joinLines(lines)
+}
+
+// renderLine renders one sample with sorted labels
+func renderLine(name string, labels map[string]string, value float64, timestamp *int64) string {
+	line := strings.Builder{}
+	line.WriteString(name)
+
+	if len(labels) > 0 {
+		line.WriteByte('{')
+		for i, labelName := range slices.Sorted(maps.Keys(labels)) {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			line.WriteString(labelName)
+			line.WriteByte('=')
+			line.WriteByte('"')
+			line.WriteString(labelValueEscaper.Replace(labels[labelName]))
+			line.WriteByte('"')
+		}
+		line.WriteByte('}')
+	}
+
+	line.WriteByte(' ')
+	line.WriteString(formatValue(value))
+
+	if timestamp != nil {
+		line.WriteByte(' ')
+		line.WriteString(strconv.FormatInt(*timestamp, 10))
+	}
+
+	return line.String()
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	slices.Sort(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -1,23 +1,69 @@
 package prometheus
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"maps"
+	"math"
 	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cache "github.com/patrickmn/go-cache"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-type HashedMetricList struct {
-	List map[string]*MetricRow `json:"list"`
-	mux  *sync.Mutex
+const (
+	HashedMetricListStripes     = 32
+	HashedMetricListBucketLabel = "le"
 
-	metricsCache *cache.Cache
-}
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+type (
+	// HashedMetricList aggregates values by label set
+	//
+	//	label sets are hashed with FNV-1a (with collision checks) and stored in lock striped maps
+	//	so multiple goroutines can write concurrently, bucket rows (see Observe) are stored in the
+	//	stripe of the label set without bucket label
+	HashedMetricList struct {
+		// Deprecated: rows are stored in lock striped maps, List is only a snapshot of the rows
+		// which is updated by GetRowMap (changes are not applied to the list). Use GetRowMap or GetList instead.
+		List    map[string]*MetricRow `json:"-"`
+		listMux sync.Mutex
+
+		stripes [HashedMetricListStripes]*hashedMetricStripe
+		compact atomic.Bool
+
+		buckets      []float64
+		bucketLabels []string
+
+		metricsCache *cache.Cache
+	}
+
+	hashedMetricStripe struct {
+		mux  sync.Mutex
+		list map[uint64]*hashedMetricEntry
+
+		// only used with compact storage
+		store *compactMetricStore
+	}
+
+	hashedMetricEntry struct {
+		// labels of row are nil with compact storage, labelRef is used instead
+		row      MetricRow
+		labelRef compactLabelRef
+
+		// next entry with same hash (collision)
+		next *hashedMetricEntry
+	}
+)
 
 func NewHashedMetricsList() *HashedMetricList {
 	m := HashedMetricList{}
@@ -26,7 +72,7 @@ func NewHashedMetricsList() *HashedMetricList {
 }
 
 func (m *HashedMetricList) Init() {
-	m.mux = &sync.Mutex{}
+	m.SetBuckets(prometheus.DefBuckets...)
 	m.Reset()
 }
 
@@ -34,16 +80,46 @@ func (m *HashedMetricList) SetCache(instance *cache.Cache) {
 	m.metricsCache = instance
 }
 
+// SetBuckets sets the buckets used by Observe
+func (m *HashedMetricList) SetBuckets(buckets ...float64) {
+	m.buckets = slices.Sorted(slices.Values(buckets))
+	m.bucketLabels = make([]string, 0, len(m.buckets)+1)
+	for _, bucket := range m.buckets {
+		m.bucketLabels = append(m.bucketLabels, strconv.FormatFloat(bucket, 'g', -1, 64))
+	}
+	m.bucketLabels = append(m.bucketLabels, "+Inf")
+}
+
+// EnableCompactStorage switches the list to compact storage with interned label strings
+//
+//	reduces memory usage for large lists, GetList has to build the label maps on each call
+//	so GaugeSet and CounterAdd should be used instead
+//
+//	the stripes are converted one by one while holding the stripe lock, so it's safe to call with concurrent writers
+func (m *HashedMetricList) EnableCompactStorage() {
+	if !m.compact.CompareAndSwap(false, true) {
+		return
+	}
+
+	for _, stripe := range m.stripes {
+		stripe.mux.Lock()
+		stripe.enableCompactStorage()
+		stripe.mux.Unlock()
+	}
+}
+
+// IsCompactStorage returns true if compact storage is enabled
+func (m *HashedMetricList) IsCompactStorage() bool {
+	return m.compact.Load()
+}
+
 func (m *HashedMetricList) LoadFromCache(key string) bool {
 	m.Reset()
 
 	if m.metricsCache != nil {
-		m.mux.Lock()
-		defer m.mux.Unlock()
-
 		if val, fetched := m.metricsCache.Get(key); fetched {
 			// loaded from cache
-			m.List = val.(map[string]*MetricRow)
+			m.setList(val.([]MetricRow))
 			return true
 		}
 	}
@@ -60,50 +136,379 @@ func (m *HashedMetricList) StoreToCache(key string, duration time.Duration) erro
 }
 
 func (m *HashedMetricList) Reset() {
-	m.mux.Lock()
-	defer m.mux.Unlock()
-	m.List = map[string]*MetricRow{}
+	for i := range m.stripes {
+		if m.stripes[i] == nil {
+			m.stripes[i] = &hashedMetricStripe{}
+		}
+
+		stripe := m.stripes[i]
+		stripe.mux.Lock()
+		stripe.list = map[uint64]*hashedMetricEntry{}
+		stripe.store = nil
+		if m.compact.Load() {
+			stripe.store = newCompactMetricStore()
+		}
+		stripe.mux.Unlock()
+	}
 }
 
-func (m *HashedMetricList) GetList() []MetricRow {
-	m.mux.Lock()
-	defer m.mux.Unlock()
+// setList adds all rows to the list, values of rows with same label set are overwritten
+func (m *HashedMetricList) setList(list []MetricRow) {
+	for _, row := range list {
+		m.update(row.Labels, func(entry *hashedMetricEntry, created bool) {
+			labels := entry.row.Labels
+			entry.row = row
+			entry.row.Labels = labels
+		})
+	}
+}
 
+func (m *HashedMetricList) GetList() []MetricRow {
 	list := []MetricRow{}
-	for _, row := range m.List {
-		list = append(list, *row)
+	for _, stripe := range m.stripes {
+		stripe.mux.Lock()
+		stripe.forEach(func(entry *hashedMetricEntry) {
+			list = append(list, stripe.row(entry, nil))
+		})
+		stripe.mux.Unlock()
 	}
 
 	return list
 }
 
-func (m *HashedMetricList) Inc(labels prometheus.Labels) {
-	m.mux.Lock()
-	defer m.mux.Unlock()
+// GetMetricRows returns the current metric rows
+func (m *HashedMetricList) GetMetricRows() []MetricRow {
+	return m.GetList()
+}
 
-	metricKey := ""
-	for _, key := range slices.Sorted(maps.Keys(labels)) {
-		metricKey = metricKey + key + "=" + labels[key] + ";"
+// GetRowMap returns a copy of the current rows by the SHA-256 hash of the label set (same keys as the former List field)
+//
+//	the deprecated List field is updated with the returned map
+func (m *HashedMetricList) GetRowMap() map[string]*MetricRow {
+	ret := map[string]*MetricRow{}
+	for _, row := range m.GetList() {
+		var metricKey strings.Builder
+		for _, name := range slices.Sorted(maps.Keys(row.Labels)) {
+			metricKey.WriteString(name + "=" + row.Labels[name] + ";")
+		}
+		ret[fmt.Sprintf("%x", sha256.Sum256([]byte(metricKey.String())))] = &row
 	}
-	hashKey := fmt.Sprintf("%x", sha256.Sum256([]byte(metricKey)))
-	if _, exists := m.List[hashKey]; exists {
-		m.List[hashKey].Value++
-	} else {
-		m.List[hashKey] = &MetricRow{
-			Labels: labels,
-			Value:  1,
+
+	m.listMux.Lock()
+	defer m.listMux.Unlock()
+	m.List = ret
+
+	return maps.Clone(ret)
+}
+
+// forEach calls the callback for every row, with compact storage the labels are only valid during the callback
+func (m *HashedMetricList) forEach(callback func(row MetricRow)) {
+	labels := prometheus.Labels{}
+	for _, stripe := range m.stripes {
+		stripe.mux.Lock()
+		stripe.forEach(func(entry *hashedMetricEntry) {
+			callback(stripe.row(entry, labels))
+		})
+		stripe.mux.Unlock()
+	}
+}
+
+// update finds or creates the entry for the label set and calls the callback while holding the stripe lock
+func (m *HashedMetricList) update(labels prometheus.Labels, callback func(entry *hashedMetricEntry, created bool)) {
+	hash := hashLabels(labels)
+	stripe := m.stripe(hash, labels)
+
+	stripe.mux.Lock()
+	defer stripe.mux.Unlock()
+
+	callback(stripe.entry(hash, labels))
+}
+
+// stripe returns the stripe of the label set, bucket rows are stored in the stripe of the label set without bucket label
+func (m *HashedMetricList) stripe(hash uint64, labels prometheus.Labels) *hashedMetricStripe {
+	if bucket, exists := labels[HashedMetricListBucketLabel]; exists {
+		hash -= hashLabelPair(HashedMetricListBucketLabel, bucket)
+	}
+	return m.stripes[hash%HashedMetricListStripes]
+}
+
+// Inc increases the value of the label set by one
+func (m *HashedMetricList) Inc(labels prometheus.Labels) {
+	m.Add(labels, 1)
+}
+
+// Add adds the value to the label set
+func (m *HashedMetricList) Add(labels prometheus.Labels, value float64) {
+	m.update(labels, func(entry *hashedMetricEntry, created bool) {
+		entry.row.Value += value
+	})
+}
+
+// Set sets the value of the label set
+func (m *HashedMetricList) Set(labels prometheus.Labels, value float64) {
+	m.update(labels, func(entry *hashedMetricEntry, created bool) {
+		entry.row.Value = value
+	})
+}
+
+// Max sets the value of the label set if it is greater than the current value
+func (m *HashedMetricList) Max(labels prometheus.Labels, value float64) {
+	m.update(labels, func(entry *hashedMetricEntry, created bool) {
+		if created {
+			entry.row.Value = value
+		} else {
+			entry.row.Value = math.Max(entry.row.Value, value)
+		}
+	})
+}
+
+// Min sets the value of the label set if it is less than the current value
+func (m *HashedMetricList) Min(labels prometheus.Labels, value float64) {
+	m.update(labels, func(entry *hashedMetricEntry, created bool) {
+		if created {
+			entry.row.Value = value
+		} else {
+			entry.row.Value = math.Min(entry.row.Value, value)
+		}
+	})
+}
+
+// Observe counts the value into cumulative buckets (see SetBuckets), the bucket is added as "le" label
+//
+//	all buckets are created (also with zero count) so the series set is stable,
+//	the bucket rows are updated together while holding the stripe lock
+//
+//	this is a gauge per bucket helper (eg. for GaugeSet), no "_sum" and "_count" series are created
+//	and the bucket rows are skipped by HistogramSet
+func (m *HashedMetricList) Observe(labels prometheus.Labels, value float64) {
+	bucketLabels := make(prometheus.Labels, len(labels)+1)
+	maps.Copy(bucketLabels, labels)
+	delete(bucketLabels, HashedMetricListBucketLabel)
+	hash := hashLabels(bucketLabels)
+	stripe := m.stripes[hash%HashedMetricListStripes]
+
+	stripe.mux.Lock()
+	defer stripe.mux.Unlock()
+
+	for i, bucket := range m.bucketLabels {
+		bucketLabels[HashedMetricListBucketLabel] = bucket
+		entry, _ := stripe.entry(hash+hashLabelPair(HashedMetricListBucketLabel, bucket), bucketLabels)
+		// last bucket is +Inf
+		if i == len(m.buckets) || value <= m.buckets[i] {
+			entry.row.Value++
 		}
 	}
 }
 
 func (m *HashedMetricList) GaugeSet(gauge *prometheus.GaugeVec) {
-	for _, metric := range m.GetList() {
+	m.forEach(func(metric MetricRow) {
 		gauge.With(metric.Labels).Set(metric.Value)
-	}
+	})
+}
+
+func (m *HashedMetricList) SummarySet(summary *prometheus.SummaryVec) {
+	m.forEach(func(metric MetricRow) {
+		summary.With(metric.Labels).Observe(metric.Value)
+	})
+}
+
+// HistogramSet observes the values of the rows with the histogram vec
+//
+//	bucket rows (with "le" label, see Observe) are skipped as histograms can't have a "le" label
+//	and the bucket counts are no samples, use GaugeSet for them instead
+func (m *HashedMetricList) HistogramSet(histogram *prometheus.HistogramVec) {
+	m.forEach(func(metric MetricRow) {
+		if _, exists := metric.Labels[HashedMetricListBucketLabel]; exists {
+			return
+		}
+		histogram.With(metric.Labels).Observe(metric.Value)
+	})
 }
 
 func (m *HashedMetricList) CounterAdd(counter *prometheus.CounterVec) {
-	for _, metric := range m.GetList() {
+	m.forEach(func(metric MetricRow) {
 		counter.With(metric.Labels).Add(metric.Value)
+	})
+}
+
+// MarshalJSON serializes the list, keys are the label set hashes
+func (m *HashedMetricList) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteString(`{"list":{`)
+
+	labels := prometheus.Labels{}
+	first := true
+	for _, stripe := range m.stripes {
+		stripe.mux.Lock()
+		for hash, entry := range stripe.list {
+			for n := 0; entry != nil; n++ {
+				if !first {
+					buf.WriteByte(',')
+				}
+				first = false
+
+				key := strconv.FormatUint(hash, 16)
+				if n > 0 {
+					key += "-" + strconv.Itoa(n)
+				}
+
+				rowJson, err := json.Marshal(stripe.row(entry, labels))
+				if err != nil {
+					stripe.mux.Unlock()
+					return nil, err
+				}
+
+				buf.WriteString(strconv.Quote(key))
+				buf.WriteByte(':')
+				buf.Write(rowJson)
+
+				entry = entry.next
+			}
+		}
+		stripe.mux.Unlock()
+	}
+
+	buf.WriteString(`}}`)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON deserializes the list, hash keys are recalculated from the label sets
+func (m *HashedMetricList) UnmarshalJSON(data []byte) error {
+	if m.stripes[0] == nil {
+		m.Init()
+	}
+
+	parsed := struct {
+		List map[string]*MetricRow `json:"list"`
+	}{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return fmt.Errorf(`unable to parse hashed metric list: %w`, err)
+	}
+
+	if parsed.List != nil {
+		list := make([]MetricRow, 0, len(parsed.List))
+		for _, row := range parsed.List {
+			if row != nil {
+				list = append(list, *row)
+			}
+		}
+
+		m.Reset()
+		m.setList(list)
 	}
+
+	return nil
+}
+
+// forEach iterates over all entries including collisions, lock must be held
+func (s *hashedMetricStripe) forEach(callback func(entry *hashedMetricEntry)) {
+	for _, entry := range s.list {
+		for ; entry != nil; entry = entry.next {
+			callback(entry)
+		}
+	}
+}
+
+// enableCompactStorage moves the labels of all entries into a compact store, lock must be held
+func (s *hashedMetricStripe) enableCompactStorage() {
+	if s.store != nil {
+		return
+	}
+
+	s.store = newCompactMetricStore()
+	s.forEach(func(entry *hashedMetricEntry) {
+		entry.labelRef = s.store.addLabels(entry.row.Labels)
+		entry.row.Labels = nil
+	})
+}
+
+// entry finds or creates the entry for the label set, lock must be held
+//
+//	the labels are copied for new entries so the caller can reuse the label map
+func (s *hashedMetricStripe) entry(hash uint64, labels prometheus.Labels) (*hashedMetricEntry, bool) {
+	var last *hashedMetricEntry
+	for entry := s.list[hash]; entry != nil; entry = entry.next {
+		if s.equalLabels(entry, labels) {
+			return entry, false
+		}
+		last = entry
+	}
+
+	entry := &hashedMetricEntry{}
+	if s.store != nil {
+		entry.labelRef = s.store.addLabels(labels)
+	} else {
+		entry.row.Labels = maps.Clone(labels)
+	}
+
+	if last != nil {
+		// hash collision, chain entry
+		last.next = entry
+	} else {
+		s.list[hash] = entry
+	}
+
+	return entry, true
+}
+
+// equalLabels checks if the entry belongs to the label set
+func (s *hashedMetricStripe) equalLabels(entry *hashedMetricEntry, labels prometheus.Labels) bool {
+	if s.store != nil {
+		return s.store.equalLabels(entry.labelRef, labels)
+	}
+
+	if len(entry.row.Labels) != len(labels) {
+		return false
+	}
+
+	for name, value := range labels {
+		if entryValue, exists := entry.row.Labels[name]; !exists || entryValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// row returns the row of the entry, with compact storage the passed label map is filled (or created if nil)
+func (s *hashedMetricStripe) row(entry *hashedMetricEntry, labels prometheus.Labels) MetricRow {
+	row := entry.row
+	if s.store != nil {
+		if labels == nil {
+			labels = make(prometheus.Labels, entry.labelRef.count)
+		}
+		s.store.fillLabelRef(entry.labelRef, labels)
+		row.Labels = labels
+	}
+	return row
+}
+
+// hashLabels calculates an order independent FNV-1a hash of the label set
+//
+//	the pair hashes are summed up, so pairs can be added or removed without rehashing the label set
+func hashLabels(labels prometheus.Labels) (hash uint64) {
+	for name, value := range labels {
+		hash += hashLabelPair(name, value)
+	}
+
+	return
+}
+
+// hashLabelPair calculates the FNV-1a hash of a label pair
+func hashLabelPair(name, value string) uint64 {
+	hash := uint64(fnvOffset64)
+	for i := 0; i < len(name); i++ {
+		hash ^= uint64(name[i])
+		hash *= fnvPrime64
+	}
+
+	hash ^= 0xff
+	hash *= fnvPrime64
+
+	for i := 0; i < len(value); i++ {
+		hash ^= uint64(value[i])
+		hash *= fnvPrime64
+	}
+
+	return hash
 }
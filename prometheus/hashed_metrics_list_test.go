@@ -1,9 +1,15 @@
 package prometheus
 
 import (
+	"encoding/json"
+	"maps"
+	"runtime"
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func Test_HashedMetricsList(t *testing.T) {
@@ -12,6 +18,211 @@ func Test_HashedMetricsList(t *testing.T) {
 	hashedMetricsListTestList(t, m)
 }
 
+func Test_HashedMetricsListOperations(t *testing.T) {
+	m := NewHashedMetricsList()
+	labels := prometheus.Labels{"key": "value"}
+
+	m.Add(labels, 5)
+	m.Add(labels, 2)
+	expectHashedListValue(t, m, labels, 7)
+
+	m.Max(labels, 3)
+	expectHashedListValue(t, m, labels, 7)
+	m.Max(labels, 10)
+	expectHashedListValue(t, m, labels, 10)
+
+	m.Min(labels, 12)
+	expectHashedListValue(t, m, labels, 10)
+	m.Min(labels, -1)
+	expectHashedListValue(t, m, labels, -1)
+
+	m.Set(labels, 42)
+	expectHashedListValue(t, m, labels, 42)
+
+	minLabels := prometheus.Labels{"key": "min"}
+	m.Min(minLabels, 5)
+	expectHashedListValue(t, m, minLabels, 5)
+
+	m.SetBuckets(1, 5, 10)
+	observeLabels := prometheus.Labels{"key": "observe"}
+	m.Observe(observeLabels, 3)
+	m.Observe(observeLabels, 7)
+	m.Observe(observeLabels, 20)
+	expectHashedListValue(t, m, prometheus.Labels{"key": "observe", "le": "1"}, 0)
+	expectHashedListValue(t, m, prometheus.Labels{"key": "observe", "le": "5"}, 1)
+	expectHashedListValue(t, m, prometheus.Labels{"key": "observe", "le": "10"}, 2)
+	expectHashedListValue(t, m, prometheus.Labels{"key": "observe", "le": "+Inf"}, 3)
+
+	// bucket rows are stored in the stripe of the label set without bucket label
+	stripe := m.stripes[hashLabels(observeLabels)%HashedMetricListStripes]
+	stripe.mux.Lock()
+	bucketRows := 0
+	stripe.forEach(func(entry *hashedMetricEntry) {
+		if entry.row.Labels["key"] == "observe" {
+			bucketRows++
+		}
+	})
+	stripe.mux.Unlock()
+	if bucketRows != 4 {
+		t.Errorf("expected 4 bucket rows in one stripe, got %v", bucketRows)
+	}
+
+	// bucket rows must not be observed as samples
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_observe"}, []string{"key"})
+	m.HistogramSet(histogram)
+	if count := testutil.CollectAndCount(histogram); count != 2 {
+		t.Errorf("expected bucket rows to be skipped, got %v series", count)
+	}
+}
+
+func Test_HashedMetricsListRowMap(t *testing.T) {
+	m := NewHashedMetricsList()
+	m.Inc(prometheus.Labels{"key": "value"})
+	m.Inc(prometheus.Labels{"key": "value"})
+
+	// same keys as the former List field (sha256 of sorted label pairs)
+	key := "0ebf94feb443d2b83f53eb1f40adf387daacf3f6d48044a334d79ee4d4526fff"
+	rowMap := m.GetRowMap()
+	if row, exists := rowMap[key]; len(rowMap) != 1 || !exists || row.Value != 2 {
+		t.Errorf("unexpected rows: %v", rowMap)
+	}
+
+	if row, exists := m.List[key]; len(m.List) != 1 || !exists || row.Value != 2 {
+		t.Errorf("expected snapshot in deprecated List field, got %v", m.List)
+	}
+}
+
+func Test_HashedMetricsListCollision(t *testing.T) {
+	m := NewHashedMetricsList()
+
+	labels := prometheus.Labels{"key": "value"}
+	hash := hashLabels(labels)
+	stripe := m.stripes[hash%HashedMetricListStripes]
+
+	// inject entry with other labels but same hash
+	stripe.list[hash] = &hashedMetricEntry{row: MetricRow{Labels: prometheus.Labels{"key": "collision"}, Value: 100}}
+
+	m.Inc(labels)
+	m.Inc(labels)
+	expectHashedListCount(t, m, 2)
+	expectHashedListValue(t, m, labels, 2)
+	expectHashedListValue(t, m, prometheus.Labels{"key": "collision"}, 100)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m2 := NewHashedMetricsList()
+	if err := json.Unmarshal(data, m2); err != nil {
+		t.Fatal(err)
+	}
+	expectHashedListCount(t, m2, 2)
+	expectHashedListValue(t, m2, labels, 2)
+}
+
+func Test_HashedMetricsListConcurrency(t *testing.T) {
+	m := NewHashedMetricsList()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				m.Inc(prometheus.Labels{"key": strconv.Itoa(n % 10)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	expectHashedListCount(t, m, 10)
+	expectHashedListValue(t, m, prometheus.Labels{"key": "3"}, 800)
+}
+
+func Test_HashedMetricsListCompact(t *testing.T) {
+	m := NewHashedMetricsList()
+	m.EnableCompactStorage()
+	hashedMetricsListGenerateMetrics(t, m)
+	hashedMetricsListTestList(t, m)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// compact storage must use the same serialization format
+	m2 := NewHashedMetricsList()
+	if err := json.Unmarshal(data, m2); err != nil {
+		t.Fatal(err)
+	}
+	hashedMetricsListTestList(t, m2)
+
+	m2.EnableCompactStorage()
+	m2.Inc(prometheus.Labels{"key": "test2", "foo": "bar"})
+	expectHashedListCount(t, m2, 3)
+}
+
+func Test_HashedMetricsListCompactConcurrency(t *testing.T) {
+	m := NewHashedMetricsList()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				m.Inc(prometheus.Labels{"key": strconv.Itoa(n % 10)})
+				if n == 500 {
+					m.EnableCompactStorage()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !m.IsCompactStorage() {
+		t.Error("expected compact storage")
+	}
+	expectHashedListCount(t, m, 10)
+	expectHashedListValue(t, m, prometheus.Labels{"key": "3"}, 800)
+}
+
+func BenchmarkHashedMetricsListIncParallel(b *testing.B) {
+	m := NewHashedMetricsList()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		labels := prometheus.Labels{"key": "info", "foo": "bar"}
+		for pb.Next() {
+			m.Inc(labels)
+		}
+	})
+}
+
+func BenchmarkHashedMetricsListInc(b *testing.B) {
+	b.Run("default", func(b *testing.B) {
+		benchmarkHashedMetricsListInc(b, NewHashedMetricsList())
+	})
+
+	b.Run("compact", func(b *testing.B) {
+		m := NewHashedMetricsList()
+		m.EnableCompactStorage()
+		benchmarkHashedMetricsListInc(b, m)
+	})
+}
+
+func benchmarkHashedMetricsListInc(b *testing.B, m *HashedMetricList) {
+	b.Helper()
+	b.ReportAllocs()
+
+	heapBefore := benchmarkHeapInUse()
+	for i := 0; i < b.N; i++ {
+		m.Inc(benchmarkResourceLabels(i))
+	}
+	b.ReportMetric(float64(benchmarkHeapInUse()-heapBefore)/float64(b.N), "heap-B/row")
+	runtime.KeepAlive(m)
+}
+
 func hashedMetricsListGenerateMetrics(t *testing.T, m *HashedMetricList) {
 	expectHashedListCount(t, m, 0)
 	m.Inc(prometheus.Labels{"key": "info", "foo": "bar"})
@@ -80,3 +291,16 @@ func expectHashedListCount(t *testing.T, m *HashedMetricList, expectedCount int)
 		t.Errorf("Expected item count: %v  Actual item count: %v", expectedCount, itemCount)
 	}
 }
+
+func expectHashedListValue(t *testing.T, m *HashedMetricList, labels prometheus.Labels, expectedValue float64) {
+	t.Helper()
+
+	for _, row := range m.GetList() {
+		if maps.Equal(row.Labels, labels) {
+			expectMetricRowValue(t, row, expectedValue)
+			return
+		}
+	}
+
+	t.Errorf("Expected metric with labels %v not found", labels)
+}
@@ -2,13 +2,10 @@ package kusto
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
-
-	"sigs.k8s.io/yaml"
+	"time"
 )
 
 const (
@@ -16,6 +13,7 @@ const (
 	MetricFieldTypeIgnore  = "ignore"
 	MetricFieldTypeId      = "id"
 	MetricFieldTypeValue   = "value"
+	MetricFieldTypeTime    = "timestamp"
 	MetricFieldTypeDefault = "string"
 	MetricFieldTypeBool    = "bool"
 	MetricFieldTypeBoolean = "boolean"
@@ -25,11 +23,34 @@ const (
 	MetricFieldFilterToTitle    = "totitle"
 	MetricFieldFilterToRegexp   = "regexp"
 	MetricFieldFilterToUnixtime = "tounixtime"
+
+	MetricFieldFilterTrim              = "trim"
+	MetricFieldFilterDefault           = "default"
+	MetricFieldFilterTruncate          = "truncate"
+	MetricFieldFilterHash              = "hash"
+	MetricFieldFilterMap               = "map"
+	MetricFieldFilterLookup            = "lookup"
+	MetricFieldFilterSplit             = "split"
+	MetricFieldFilterUrlParse          = "urlparse"
+	MetricFieldFilterJsonPath          = "jsonpath"
+	MetricFieldFilterDurationToSeconds = "durationtoseconds"
+	MetricFieldFilterBytesToNumber     = "bytestonumber"
+
+	QueryModeWorkspace     = "workspace"
+	QueryModeResourceGraph = "resourcegraph"
+
+	MetricTypeGauge     = "gauge"
+	MetricTypeCounter   = "counter"
+	MetricTypeInfo      = "info"
+	MetricTypeHistogram = "histogram"
 )
 
 type (
 	Config struct {
 		Queries []Query `json:"queries"`
+
+		// source contains the file and line numbers of the parsed config (used for validation errors)
+		source *configSource
 	}
 
 	Query struct {
@@ -40,10 +61,20 @@ type (
 		Module        string    `json:"module"`
 		Query         string    `json:"query"`
 		Timespan      *string   `json:"timespan"`
+		Interval      *string   `json:"interval"`
 		Subscriptions *[]string `json:"subscriptions"`
+
+		// Parameters are rendered into the query (see RenderQuery)
+		Parameters []QueryParameter `json:"parameters"`
+
+		// DefaultLabelValue is used for labels which are missing in some result rows
+		DefaultLabelValue *string `json:"defaultLabelValue"`
+
+		ManagementGroups *[]string `json:"managementGroups"`
 	}
 
 	QueryMetric struct {
+		MetricMetadata
 		Value        *float64          `json:"value"`
 		Fields       []MetricField     `json:"fields"`
 		Labels       map[string]string `json:"labels"`
@@ -52,6 +83,7 @@ type (
 	}
 
 	MetricField struct {
+		MetricMetadata
 		Name    string              `json:"name"`
 		Metric  string              `json:"metric"`
 		Source  string              `json:"source"`
@@ -62,11 +94,24 @@ type (
 		Expand  *QueryMetric        `json:"expand"`
 	}
 
+	// MetricMetadata describes the metric which is built by a query or a field with own metric (or expand)
+	//
+	//	metricType is used as key because type of fields is already the field type
+	MetricMetadata struct {
+		MetricType string    `json:"metricType"`
+		Help       string    `json:"help"`
+		Unit       string    `json:"unit"`
+		Buckets    []float64 `json:"buckets"`
+	}
+
 	MetricFieldFilter struct {
-		Type         string `json:"type"`
-		RegExp       string `json:"regexp"`
-		Replacement  string `json:"replacement"`
-		parsedRegexp *regexp.Regexp
+		Type        string `json:"type"`
+		RegExp      string `json:"regexp"`
+		Replacement string `json:"replacement"`
+
+		// raw contains the filter configuration (see DecodeConfig)
+		raw    json.RawMessage
+		filter FieldFilter
 	}
 
 	MetricFieldFilterParser struct {
@@ -76,77 +121,88 @@ type (
 	}
 )
 
-func (c *Config) Validate() error {
-	if len(c.Queries) == 0 {
-		return errors.New("no queries found")
+// GetInterval returns the execution interval of the query (0 if the query is executed on each run)
+func (c *Query) GetInterval() (time.Duration, error) {
+	if c.Interval == nil || *c.Interval == "" {
+		return 0, nil
 	}
 
-	for _, queryConfig := range c.Queries {
-		if err := queryConfig.Validate(); err != nil {
-			return fmt.Errorf("query \"%v\": %w", queryConfig.QueryMetric, err)
-		}
+	interval, err := time.ParseDuration(*c.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval \"%v\": %w", *c.Interval, err)
 	}
-
-	return nil
+	return interval, nil
 }
 
-func (c *Query) Validate() error {
-	if err := c.QueryMetric.Validate(); err != nil {
-		return err
+// GetQueryMode returns the query mode (workspace if not set)
+func (c *Query) GetQueryMode() (ret string) {
+	ret = strings.ToLower(c.QueryMode)
+	if ret == "" {
+		ret = QueryModeWorkspace
 	}
-
-	return nil
+	return
 }
 
-func (c *QueryMetric) Validate() error {
-	// validate default field
-	c.DefaultField.Name = "default"
-	if err := c.DefaultField.Validate(); err != nil {
-		return err
+// GetWorkspaces returns the list of Log Analytics workspace ids of the query
+func (c *Query) GetWorkspaces() []string {
+	if c.Workspaces == nil {
+		return []string{}
 	}
+	return *c.Workspaces
+}
 
-	// validate fields
-	for _, field := range c.Fields {
-		if err := field.Validate(); err != nil {
-			return err
-		}
+// GetSubscriptions returns the list of subscription ids of the query (resourcegraph mode)
+func (c *Query) GetSubscriptions() []string {
+	if c.Subscriptions == nil {
+		return []string{}
 	}
-
-	return nil
+	return *c.Subscriptions
 }
 
-func (c *QueryMetric) IsPublished() bool {
-	if c.Publish != nil {
-		return *c.Publish
+// GetManagementGroups returns the list of management group scopes of the query (resourcegraph mode)
+func (c *Query) GetManagementGroups() []string {
+	if c.ManagementGroups == nil {
+		return []string{}
 	}
+	return *c.ManagementGroups
+}
 
-	return true
+// GetTimespan returns the ISO8601 timespan of the query (empty if not set)
+func (c *Query) GetTimespan() string {
+	if c.Timespan == nil {
+		return ""
+	}
+	return *c.Timespan
 }
 
-func (c *MetricField) Validate() error {
-	if c.Name == "" {
-		return errors.New("no field name set")
+// GetDefaultLabelValue returns the value for missing labels (empty if not set)
+func (c *Query) GetDefaultLabelValue() string {
+	if c.DefaultLabelValue == nil {
+		return ""
 	}
+	return *c.DefaultLabelValue
+}
 
-	switch c.GetType() {
-	case MetricFieldTypeDefault:
-	case MetricFieldTypeBool:
-	case MetricFieldTypeBoolean:
-	case MetricFieldTypeExpand:
-	case MetricFieldTypeId:
-	case MetricFieldTypeValue:
-	case MetricFieldTypeIgnore:
-	default:
-		return fmt.Errorf("field \"%s\": unsupported type \"%s\"", c.Name, c.GetType())
+// GetMetricType returns the metric type (gauge if not set)
+func (m *MetricMetadata) GetMetricType() (ret string) {
+	ret = strings.ToLower(m.MetricType)
+	if ret == "" {
+		ret = MetricTypeGauge
 	}
+	return
+}
 
-	for _, filter := range c.Filters {
-		if err := filter.Validate(); err != nil {
-			return fmt.Errorf("field \"%v\": %w", c.Name, err)
-		}
+// IsEmpty returns true if no metadata is set
+func (m *MetricMetadata) IsEmpty() bool {
+	return m.MetricType == "" && m.Help == "" && m.Unit == "" && len(m.Buckets) == 0
+}
+
+func (c *QueryMetric) IsPublished() bool {
+	if c.Publish != nil {
+		return *c.Publish
 	}
 
-	return nil
+	return true
 }
 
 func (c *MetricField) GetType() (ret string) {
@@ -159,27 +215,6 @@ func (c *MetricField) GetType() (ret string) {
 	return
 }
 
-func (c *MetricFieldFilter) Validate() error {
-	if c.Type == "" {
-		return errors.New("no type name set")
-	}
-
-	switch strings.ToLower(c.Type) {
-	case MetricFieldFilterToLower:
-	case MetricFieldFilterToUpper:
-	case MetricFieldFilterToTitle:
-	case MetricFieldFilterToRegexp:
-		if c.RegExp == "" {
-			return errors.New("no regexp for filter set")
-		}
-		c.parsedRegexp = regexp.MustCompile(c.RegExp)
-	default:
-		return fmt.Errorf("filter \"%v\" not supported", c.Type)
-	}
-
-	return nil
-}
-
 func (m *QueryMetric) IsExpand(field string) bool {
 	for _, fieldConfig := range m.Fields {
 		if fieldConfig.Name == field {
@@ -234,6 +269,10 @@ func (f *MetricField) IsTypeValue() bool {
 	return f.GetType() == MetricFieldTypeValue
 }
 
+func (f *MetricField) IsTypeTimestamp() bool {
+	return f.GetType() == MetricFieldTypeTime
+}
+
 func (f *MetricField) GetTargetFieldName(sourceName string) (ret string) {
 	ret = sourceName
 	if f.Target != "" {
@@ -262,22 +301,8 @@ func (f *MetricField) TransformString(value string) (ret string) {
 			ret = "false"
 		}
 	}
-	for _, filter := range f.Filters {
-		switch strings.ToLower(filter.Type) {
-		case MetricFieldFilterToLower:
-			ret = strings.ToLower(ret)
-		case MetricFieldFilterToUpper:
-			ret = strings.ToUpper(ret)
-		case MetricFieldFilterToTitle:
-			ret = strings.ToTitle(ret)
-		case MetricFieldFilterToUnixtime:
-			ret = convertStringToUnixtime(ret)
-		case MetricFieldFilterToRegexp:
-			if filter.parsedRegexp == nil {
-				filter.parsedRegexp = regexp.MustCompile(filter.RegExp)
-			}
-			ret = filter.parsedRegexp.ReplaceAllString(ret, filter.Replacement)
-		}
+	for i := range f.Filters {
+		ret = f.Filters[i].Transform(ret)
 	}
 	return
 }
@@ -312,25 +337,26 @@ func (f *MetricFieldFilter) UnmarshalJSON(c []byte) error {
 		f.Type = multi.Type
 		f.RegExp = multi.RegExp
 		f.Replacement = multi.Replacement
+		f.raw = append(json.RawMessage{}, c...)
 	}
 	return nil
 }
 
-func NewConfig(path string) (config Config) {
-	var filecontent []byte
+func (f MetricFieldFilter) MarshalJSON() ([]byte, error) {
+	if len(f.raw) > 0 {
+		return f.raw, nil
+	}
 
-	config = Config{}
+	return json.Marshal(MetricFieldFilterParser{Type: f.Type, RegExp: f.RegExp, Replacement: f.Replacement})
+}
 
+// NewConfig reads, parses and validates the kusto config file
+func NewConfig(path string) (Config, error) {
 	/*  #nosec G304 */
-	if data, err := os.ReadFile(path); err == nil {
-		filecontent = data
-	} else {
-		panic(err)
-	}
-
-	if err := yaml.Unmarshal(filecontent, &config); err != nil {
-		panic(err)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
 	}
 
-	return
+	return parseConfig(path, data)
 }
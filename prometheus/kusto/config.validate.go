@@ -0,0 +1,539 @@
+package kusto
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	yamlv3 "go.yaml.in/yaml/v3"
+	"sigs.k8s.io/yaml"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+var (
+	configQueryPathRegexp = regexp.MustCompile(`^queries\[(\d+)\]`)
+)
+
+type (
+	// ConfigError is a problem of the kusto config with the position in the config file (if known)
+	ConfigError struct {
+		File    string
+		Line    int
+		Query   string
+		Path    string
+		Message string
+	}
+
+	// ConfigErrors contains all problems of the kusto config
+	ConfigErrors []*ConfigError
+
+	// configSource contains the file and the line numbers of all config paths (eg. queries[0].fields[1])
+	configSource struct {
+		file  string
+		lines map[string]int
+	}
+
+	configValidator struct {
+		config *Config
+		errors ConfigErrors
+	}
+
+	configFieldType struct {
+		name string
+		t    reflect.Type
+	}
+
+	configMetricLabels struct {
+		query  string
+		labels []string
+	}
+
+	configMetricMetadata struct {
+		query    string
+		metadata MetricMetadata
+	}
+)
+
+func (e *ConfigError) Error() string {
+	ret := ""
+	if e.File != "" {
+		ret += e.File
+		if e.Line > 0 {
+			ret += ":" + strconv.Itoa(e.Line)
+		}
+		ret += ": "
+	}
+
+	if e.Query != "" {
+		ret += fmt.Sprintf("query \"%v\": ", e.Query)
+	}
+
+	if e.Path != "" {
+		ret += e.Path + ": "
+	}
+
+	return ret + e.Message
+}
+
+func (e ConfigErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseConfig parses and validates the kusto config (YAML or JSON)
+func ParseConfig(data []byte) (Config, error) {
+	return parseConfig("", data)
+}
+
+// parseConfig parses the config, reports unknown keys and validates the config
+func parseConfig(file string, data []byte) (Config, error) {
+	config := Config{}
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		if file != "" {
+			return config, fmt.Errorf("%v: %w", file, err)
+		}
+		return config, err
+	}
+
+	config.source = &configSource{file: file, lines: map[string]int{}}
+
+	validator := &configValidator{config: &config}
+
+	node := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(data, &node); err == nil {
+		config.source.walk(&node, reflect.TypeOf(config), "", validator.report)
+	}
+
+	config.validate(validator)
+
+	return config, validator.err()
+}
+
+// walk records the line numbers of all paths and reports unknown keys
+func (s *configSource) walk(node *yamlv3.Node, t reflect.Type, path string, report func(path, format string, args ...interface{})) {
+	if node.Kind == yamlv3.DocumentNode {
+		for _, child := range node.Content {
+			s.walk(child, t, path, report)
+		}
+		return
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			return
+		}
+
+		if t == reflect.TypeOf(MetricFieldFilter{}) {
+			// filter configuration depends on filter type (validated by filter)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				s.lines[joinConfigPath(path, node.Content[i].Value)] = node.Content[i].Line
+			}
+			return
+		}
+
+		fields := configFieldTypes(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			field, exists := fields[strings.ToLower(key.Value)]
+			childPath := joinConfigPath(path, key.Value)
+			if !exists {
+				s.lines[childPath] = key.Line
+				report(childPath, "unknown key \"%v\"", key.Value)
+				continue
+			}
+
+			childPath = joinConfigPath(path, field.name)
+			s.lines[childPath] = key.Line
+			s.walk(value, field.t, childPath, report)
+		}
+	case reflect.Slice:
+		if node.Kind != yamlv3.SequenceNode {
+			return
+		}
+
+		for i, item := range node.Content {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			s.lines[childPath] = item.Line
+			s.walk(item, t.Elem(), childPath, report)
+		}
+	case reflect.Map:
+		if node.Kind != yamlv3.MappingNode {
+			return
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			s.lines[joinConfigPath(path, node.Content[i].Value)] = node.Content[i].Line
+		}
+	}
+}
+
+// line returns the line number of the path (or of the nearest parent path)
+func (s *configSource) line(path string) int {
+	for path != "" {
+		if line, exists := s.lines[path]; exists {
+			return line
+		}
+
+		if i := strings.LastIndexAny(path, ".["); i >= 0 {
+			path = path[:i]
+		} else {
+			break
+		}
+	}
+
+	return 0
+}
+
+// configFieldTypes returns the json fields of the struct (lowercase names as keys, embedded structs are inlined)
+func configFieldTypes(t reflect.Type) map[string]configFieldType {
+	fields := map[string]configFieldType{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			maps.Copy(fields, configFieldTypes(embedded))
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		fields[strings.ToLower(name)] = configFieldType{name: name, t: field.Type}
+	}
+	return fields
+}
+
+func joinConfigPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// report adds a problem for the config path
+func (v *configValidator) report(path, format string, args ...interface{}) {
+	err := &ConfigError{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	}
+
+	if v.config != nil {
+		if v.config.source != nil {
+			err.File = v.config.source.file
+			err.Line = v.config.source.line(path)
+		}
+
+		if match := configQueryPathRegexp.FindStringSubmatch(path); match != nil {
+			if i, _ := strconv.Atoi(match[1]); i < len(v.config.Queries) {
+				err.Query = v.config.Queries[i].GetName(i)
+			}
+		}
+	}
+
+	v.errors = append(v.errors, err)
+}
+
+// err returns all problems as ConfigErrors (or nil if there are no problems)
+func (v *configValidator) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+// Validate checks the config and returns all problems as ConfigErrors
+func (c *Config) Validate() error {
+	validator := &configValidator{config: c}
+	c.validate(validator)
+	return validator.err()
+}
+
+func (c *Config) validate(v *configValidator) {
+	if len(c.Queries) == 0 {
+		v.report("queries", "no queries found")
+		return
+	}
+
+	metricLabels := map[string]configMetricLabels{}
+	metricMetadata := map[string]configMetricMetadata{}
+	for i := range c.Queries {
+		query := &c.Queries[i]
+		path := fmt.Sprintf("queries[%d]", i)
+		query.validate(v, path)
+
+		if query.Metric == "" {
+			continue
+		}
+
+		metadata, err := query.GetMetricMetadata(query.Metric)
+		if err != nil {
+			v.report(path, "%v", err)
+		}
+
+		for _, metricName := range slices.Sorted(maps.Keys(metadata)) {
+			if unit := metadata[metricName].Unit; unit != "" && !strings.HasSuffix(metricName, "_"+unit) && !strings.HasSuffix(metricName, "_"+unit+"_total") {
+				v.report(path, "metric \"%v\" has to end with unit \"_%v\"", metricName, unit)
+			}
+
+			if existing, exists := metricMetadata[metricName]; exists {
+				if _, err := mergeMetricMetadata(metricName, existing.metadata, metadata[metricName]); err != nil {
+					v.report(path, "%v (also defined by query \"%v\")", err, existing.query)
+					continue
+				}
+			}
+			metricMetadata[metricName] = configMetricMetadata{query: query.GetName(i), metadata: metadata[metricName]}
+		}
+
+		// labels depending on the query result can not be compared
+		schema, err := query.GetMetricLabelNames(query.Metric)
+		if err != nil {
+			continue
+		}
+
+		for _, metricName := range slices.Sorted(maps.Keys(schema)) {
+			labels := schema[metricName]
+			if existing, exists := metricLabels[metricName]; exists && !slices.Equal(existing.labels, labels) {
+				v.report(path, "metric \"%v\" is also defined by query \"%v\" with different labels %v, got %v", metricName, existing.query, existing.labels, labels)
+				continue
+			}
+			metricLabels[metricName] = configMetricLabels{query: query.GetName(i), labels: labels}
+		}
+	}
+}
+
+// GetName returns the name of the query for messages (metric name or index if not set)
+func (c *Query) GetName(index int) string {
+	if c.Metric != "" {
+		return c.Metric
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+// Validate checks the query and returns all problems as ConfigErrors
+func (c *Query) Validate() error {
+	validator := &configValidator{}
+	c.validate(validator, "")
+	return validator.err()
+}
+
+func (c *Query) validate(v *configValidator, path string) {
+	if c.QueryMetric == nil {
+		c.QueryMetric = &QueryMetric{}
+	}
+
+	if c.Metric == "" {
+		v.report(path, "no metric name set")
+	} else if !prometheusCommon.IsValidMetricName(c.Metric) {
+		v.report(joinConfigPath(path, "metric"), "invalid metric name \"%v\"", c.Metric)
+	}
+
+	if strings.TrimSpace(c.Query) == "" {
+		v.report(joinConfigPath(path, "query"), "empty query")
+	}
+
+	switch c.GetQueryMode() {
+	case QueryModeWorkspace:
+	case QueryModeResourceGraph:
+	default:
+		v.report(joinConfigPath(path, "queryMode"), "unsupported queryMode \"%v\"", c.QueryMode)
+	}
+
+	if _, err := c.GetInterval(); err != nil {
+		v.report(joinConfigPath(path, "interval"), "%v", err)
+	}
+
+	parameterNames := map[string]bool{}
+	for i := range c.Parameters {
+		parameter := &c.Parameters[i]
+		parameterPath := fmt.Sprintf("%s[%d]", joinConfigPath(path, "parameters"), i)
+		parameterNames[parameter.Name] = true
+		parameter.validate(v, parameterPath)
+
+		for _, other := range c.Parameters[:i] {
+			if other.Name == parameter.Name {
+				v.report(parameterPath, "duplicate parameter \"%v\"", parameter.Name)
+				break
+			}
+		}
+	}
+
+	if len(c.Parameters) > 0 && c.IsTemplate() {
+		if tmpl, err := c.parseQueryTemplate(); err != nil {
+			v.report(joinConfigPath(path, "query"), "%v", err)
+		} else if err := tmpl.Execute(io.Discard, parameterNames); err != nil {
+			v.report(joinConfigPath(path, "query"), "unable to render query template: %v", err)
+		}
+	}
+
+	c.QueryMetric.validate(v, path)
+}
+
+func (p *QueryParameter) validate(v *configValidator, path string) {
+	if !queryParameterNameRegexp.MatchString(p.Name) {
+		v.report(path, "invalid parameter name \"%v\"", p.Name)
+	}
+
+	switch p.GetType() {
+	case QueryParameterTypeString:
+	case QueryParameterTypeLong:
+	case QueryParameterTypeReal:
+	case QueryParameterTypeBool:
+	case QueryParameterTypeTimespan:
+	case QueryParameterTypeDatetime:
+	case QueryParameterTypeDynamic:
+	default:
+		v.report(joinConfigPath(path, "type"), "parameter \"%v\": unsupported type \"%v\"", p.Name, p.Type)
+		return
+	}
+
+	if p.Value == nil && p.Env == "" {
+		v.report(path, "parameter \"%v\": no value or env var set", p.Name)
+	} else if p.Value != nil {
+		// value from env var is checked when the query is rendered
+		if _, err := formatQueryParameterLiteral(p.GetType(), p.Value); err != nil {
+			v.report(joinConfigPath(path, "value"), "parameter \"%v\": %v", p.Name, err)
+		}
+	}
+}
+
+// Validate checks the metric configuration and returns all problems as ConfigErrors
+func (c *QueryMetric) Validate() error {
+	validator := &configValidator{}
+	c.validate(validator, "")
+	return validator.err()
+}
+
+func (c *QueryMetric) validate(v *configValidator, path string) {
+	c.MetricMetadata.validate(v, path)
+
+	for _, labelName := range slices.Sorted(maps.Keys(c.Labels)) {
+		if !prometheusCommon.IsValidLabelName(labelName) {
+			v.report(joinConfigPath(path, "labels."+labelName), "invalid label name \"%v\"", labelName)
+		}
+	}
+
+	// validate default field
+	c.DefaultField.Name = "default"
+	c.DefaultField.validate(v, joinConfigPath(path, "defaultField"), true)
+
+	// validate fields
+	for i := range c.Fields {
+		c.Fields[i].validate(v, fmt.Sprintf("%s[%d]", joinConfigPath(path, "fields"), i), false)
+	}
+}
+
+// Validate checks the field configuration and returns all problems as ConfigErrors
+func (c *MetricField) Validate() error {
+	validator := &configValidator{}
+	c.validate(validator, "", false)
+	return validator.err()
+}
+
+func (c *MetricField) validate(v *configValidator, path string, isDefaultField bool) {
+	if c.Name == "" {
+		v.report(path, "no field name set")
+	}
+
+	switch c.GetType() {
+	case MetricFieldTypeDefault:
+	case MetricFieldTypeBool:
+	case MetricFieldTypeBoolean:
+	case MetricFieldTypeExpand:
+	case MetricFieldTypeId:
+	case MetricFieldTypeValue:
+	case MetricFieldTypeTime:
+	case MetricFieldTypeIgnore:
+	default:
+		v.report(joinConfigPath(path, "type"), "field \"%s\": unsupported type \"%s\"", c.Name, c.GetType())
+	}
+
+	if c.Metric != "" && !prometheusCommon.IsValidMetricName(c.Metric) {
+		v.report(joinConfigPath(path, "metric"), "field \"%s\": invalid metric name \"%v\"", c.Name, c.Metric)
+	}
+
+	// metadata of fields without own metric would change the main metric
+	if !c.MetricMetadata.IsEmpty() && c.Metric == "" && !c.IsExpand() {
+		v.report(path, "field \"%s\": metricType, help, unit and buckets need an own metric", c.Name)
+	}
+	c.MetricMetadata.validate(v, path)
+
+	// label name of the default field depends on the column name
+	if !isDefaultField && c.Name != "" && !c.IsExpand() && isDynamicLabelField(*c) {
+		if labelName := c.GetTargetFieldName(c.GetSourceField()); !prometheusCommon.IsValidLabelName(labelName) {
+			v.report(path, "field \"%s\": invalid label name \"%v\"", c.Name, labelName)
+		}
+	}
+
+	for _, labelName := range slices.Sorted(maps.Keys(c.Labels)) {
+		if !prometheusCommon.IsValidLabelName(labelName) {
+			v.report(joinConfigPath(path, "labels."+labelName), "field \"%s\": invalid label name \"%v\"", c.Name, labelName)
+		}
+	}
+
+	for i := range c.Filters {
+		filterPath := fmt.Sprintf("%s[%d]", joinConfigPath(path, "filters"), i)
+		if err := c.Filters[i].Validate(); err != nil {
+			v.report(filterPath, "field \"%v\": %v", c.Name, err)
+		}
+	}
+
+	if c.Expand != nil {
+		c.Expand.validate(v, joinConfigPath(path, "expand"))
+	}
+}
+
+func (c *MetricMetadata) validate(v *configValidator, path string) {
+	switch c.GetMetricType() {
+	case MetricTypeGauge:
+	case MetricTypeCounter:
+	case MetricTypeInfo:
+	case MetricTypeHistogram:
+	default:
+		v.report(joinConfigPath(path, "metricType"), "unsupported metricType \"%v\"", c.MetricType)
+	}
+
+	if len(c.Buckets) > 0 {
+		if c.GetMetricType() != MetricTypeHistogram {
+			v.report(joinConfigPath(path, "buckets"), "buckets need metricType \"%v\"", MetricTypeHistogram)
+		} else if !slices.IsSorted(c.Buckets) || len(slices.Compact(slices.Clone(c.Buckets))) != len(c.Buckets) {
+			v.report(joinConfigPath(path, "buckets"), "buckets have to be sorted and unique")
+		}
+	}
+
+	if c.Unit != "" && !prometheusCommon.IsValidLabelName(c.Unit) {
+		v.report(joinConfigPath(path, "unit"), "invalid unit \"%v\"", c.Unit)
+	}
+}
+
+// Validate checks the filter type and configuration and creates the filter
+func (c *MetricFieldFilter) Validate() error {
+	filter, err := c.buildFilter()
+	if err != nil {
+		return err
+	}
+	c.filter = filter
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package kusto
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testInvalidConfig = `queries:
+  - metric: azure_loganalytics_count
+    query: "Heartbeat | summarize count_=count() by resourceId"
+    defaultField:
+      type: ignore
+    fields:
+      - name: resourceId
+        type: id
+      - name: count_
+        type: value
+  - metric: azure_loganalytics_count
+    query: "Heartbeat | summarize count_=count() by location"
+    defaultField:
+      type: ignore
+    fields:
+      - name: location
+      - name: count_
+        type: value
+  - metric: invalid-metric
+    query: " "
+    labels:
+      invalid-label: foo
+    fields:
+      - name: resource-id
+        filters:
+          - type: regexp
+            regexp: "("
+        unknownKey: true
+`
+
+func Test_ConfigValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testInvalidConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewConfig(path)
+
+	var configErrors ConfigErrors
+	if !errors.As(err, &configErrors) {
+		t.Fatalf("expected config errors, got %v", err)
+	}
+
+	expected := []string{
+		path + `:28: query "invalid-metric": queries[2].fields[0].unknownKey: unknown key "unknownKey"`,
+		path + `:11: query "azure_loganalytics_count": queries[1]: metric "azure_loganalytics_count" is also defined by query "azure_loganalytics_count" with different labels [resourceId], got [location]`,
+		path + `:19: query "invalid-metric": queries[2].metric: invalid metric name "invalid-metric"`,
+		path + `:20: query "invalid-metric": queries[2].query: empty query`,
+		path + `:22: query "invalid-metric": queries[2].labels.invalid-label: invalid label name "invalid-label"`,
+		path + `:24: query "invalid-metric": queries[2].fields[0]: field "resource-id": invalid label name "resource-id"`,
+		path + `:26: query "invalid-metric": queries[2].fields[0].filters[0]: field "resource-id": filter "regexp": invalid regexp "(": error parsing regexp: missing closing ): ` + "`(`",
+	}
+
+	actual := strings.Split(err.Error(), "\n")
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected config errors:\n%v\n\nexpected:\n%v", strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func Test_ConfigValid(t *testing.T) {
+	config, err := ParseConfig([]byte(testProcessorConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Queries) != 1 || config.Queries[0].Fields[1].Filters[0].Type != MetricFieldFilterToLower {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	if _, err := NewConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing config file")
+	}
+}
+
+func Test_ConfigMetricMetadataValidation(t *testing.T) {
+	_, err := parseConfig("queries.yaml", []byte(`queries:
+  - metric: azure_loganalytics_requests_total
+    metricType: counter
+    query: "AppRequests | summarize count_=count() by AppRoleName"
+    defaultField:
+      type: ignore
+    fields:
+      - name: AppRoleName
+      - name: count_
+        type: value
+  - metric: azure_loganalytics_requests_total
+    metricType: gauge
+    query: "AppRequests | summarize count_=count() by AppRoleName"
+    defaultField:
+      type: ignore
+    fields:
+      - name: AppRoleName
+        help: role
+      - name: count_
+        type: value
+      - name: duration
+        metric: azure_loganalytics_request_duration
+        metricType: summary
+        unit: seconds
+        buckets: [1, 0.5]
+`))
+
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	expected := []string{
+		`queries.yaml:17: query "azure_loganalytics_requests_total": queries[1].fields[0]: field "AppRoleName": metricType, help, unit and buckets need an own metric`,
+		`queries.yaml:23: query "azure_loganalytics_requests_total": queries[1].fields[2].metricType: unsupported metricType "summary"`,
+		`queries.yaml:25: query "azure_loganalytics_requests_total": queries[1].fields[2].buckets: buckets need metricType "histogram"`,
+		`queries.yaml:11: query "azure_loganalytics_requests_total": queries[1]: metric "azure_loganalytics_request_duration" has to end with unit "_seconds"`,
+		`queries.yaml:11: query "azure_loganalytics_requests_total": queries[1]: metric "azure_loganalytics_requests_total": conflicting metric types "counter" and "gauge" (also defined by query "azure_loganalytics_requests_total")`,
+	}
+
+	actual := strings.Split(err.Error(), "\n")
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected config errors:\n%v\n\nexpected:\n%v", strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+	}
+}
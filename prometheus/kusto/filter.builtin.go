@@ -0,0 +1,408 @@
+package kusto
+
+import (
+	"crypto/sha1" // #nosec G505 only used for label values
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	FieldFilterHashSha256 = "sha256"
+	FieldFilterHashSha1   = "sha1"
+
+	FieldFilterUrlPartScheme   = "scheme"
+	FieldFilterUrlPartUser     = "user"
+	FieldFilterUrlPartHost     = "host"
+	FieldFilterUrlPartHostname = "hostname"
+	FieldFilterUrlPartPort     = "port"
+	FieldFilterUrlPartPath     = "path"
+	FieldFilterUrlPartQuery    = "query"
+	FieldFilterUrlPartFragment = "fragment"
+)
+
+var (
+	iso8601DurationRegexp = regexp.MustCompile(`^(-)?P(?:(\d+(?:\.\d+)?)W)?(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+	timespanRegexp        = regexp.MustCompile(`^(-)?(?:(\d+)\.)?(\d{1,2}):(\d{2}):(\d{2}(?:\.\d+)?)$`)
+	jsonPathRegexp        = regexp.MustCompile(`[^.\[\]]+|\[\d+\]`)
+)
+
+// ------------------------------------------------------------------------------------------------
+// regexp: replaces matches of regexp with replacement
+
+type regexpFieldFilter struct {
+	regexp      *regexp.Regexp
+	replacement string
+}
+
+func newRegexpFieldFilter(config *MetricFieldFilter) (FieldFilter, error) {
+	opts := struct {
+		RegExp      string `json:"regexp"`
+		Replacement string `json:"replacement"`
+	}{RegExp: config.RegExp, Replacement: config.Replacement}
+	if err := config.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	if opts.RegExp == "" {
+		return nil, errors.New("no regexp for filter set")
+	}
+
+	parsedRegexp, err := regexp.Compile(opts.RegExp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regexp \"%v\": %w", opts.RegExp, err)
+	}
+
+	return &regexpFieldFilter{regexp: parsedRegexp, replacement: opts.Replacement}, nil
+}
+
+func (f *regexpFieldFilter) Transform(value string) string {
+	return f.regexp.ReplaceAllString(value, f.replacement)
+}
+
+// ------------------------------------------------------------------------------------------------
+// trim: removes whitespaces (or chars) at start and end
+
+func newTrimFieldFilter(config *MetricFieldFilter) (FieldFilter, error) {
+	opts := struct {
+		Chars string `json:"chars"`
+	}{}
+	if err := config.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Chars == "" {
+		return FieldFilterFunc(strings.TrimSpace), nil
+	}
+
+	return FieldFilterFunc(func(value string) string {
+		return strings.Trim(value, opts.Chars)
+	}), nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// default: sets value if empty
+
+func newDefaultFieldFilter(config *MetricFieldFilter) (FieldFilter, error) {
+	opts := struct {
+		Value *string `json:"value"`
+	}{}
+	if err := config.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Value == nil {
+		return nil, errors.New("no value set")
+	}
+
+	return FieldFilterFunc(func(value string) string {
+		if strings.TrimSpace(value) == "" {
+			return *opts.Value
+		}
+		return value
+	}), nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// truncate: limits value to length (characters)
+
+func newTruncateFieldFilter(config *MetricFieldFilter) (FieldFilter, error) {
+	opts := struct {
+		Length int `json:"length"`
+	}{}
+	if err := config.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Length <= 0 {
+		return nil, errors.New("length must be greater than zero")
+	}
+
+	return FieldFilterFunc(func(value string) string {
+		if runes := []rune(value); len(runes) > opts.Length {
+			return string(runes[:opts.Length])
+		}
+		return value
+	}), nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// hash: replaces value with hex encoded hash (eg. to hide personal data)
+
+func newHashFieldFilter(config *MetricFieldFilter) (FieldFilter, error) {
+	opts := struct {
+		Algorithm string `json:"algorithm"`
+		Length    int    `json:"length"`
+	}{}
+	if err := config.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	var hasher func() hash.Hash
+	switch strings.ToLower(opts.Algorithm) {
+	case "", FieldFilterHashSha256:
+		hasher = sha256.New
+	case FieldFilterHashSha1:
+		hasher = sha1.New // #nosec G401 only used for label values
+	default:
+		return nil, fmt.Errorf("unsupported algorithm \"%v\"", opts.Algorithm)
+	}
+
+	if opts.Length < 0 {
+		return nil, errors.New("length must not be negative")
+	}
+
+	return FieldFilterFunc(func(value string) string {
+		h := hasher()
+		h.Write([]byte(value))
+		ret := hex.EncodeToString(h.Sum(nil))
+		if opts.Length > 0 && len(ret) > opts.Length {
+			ret = ret[:opts.Length]
+		}
+		return ret
+	}), nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// map/lookup: replaces value using a lookup table
+
+func newMapFieldFilter(config *MetricFieldFilter) (FieldFilter, error) {
+	opts := struct {
+		Values     map[string]string `json:"values"`
+		Default    *string           `json:"default"`
+		IgnoreCase bool              `json:"ignoreCase"`
+	}{}
+	if err := config.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	if len(opts.Values) == 0 {
+		return nil, errors.New("no values set")
+	}
+
+	values := opts.Values
+	if opts.IgnoreCase {
+		values = make(map[string]string, len(opts.Values))
+		for key, value := range opts.Values {
+			values[strings.ToLower(key)] = value
+		}
+	}
+
+	return FieldFilterFunc(func(value string) string {
+		key := value
+		if opts.IgnoreCase {
+			key = strings.ToLower(key)
+		}
+
+		if ret, exists := values[key]; exists {
+			return ret
+		}
+
+		if opts.Default != nil {
+			return *opts.Default
+		}
+		return value
+	}), nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// split: splits value by separator and picks one part (negative index counts from end)
+
+func newSplitFieldFilter(config *MetricFieldFilter) (FieldFilter, error) {
+	opts := struct {
+		Separator string `json:"separator"`
+		Index     int    `json:"index"`
+	}{}
+	if err := config.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Separator == "" {
+		return nil, errors.New("no separator set")
+	}
+
+	return FieldFilterFunc(func(value string) string {
+		parts := strings.Split(value, opts.Separator)
+
+		index := opts.Index
+		if index < 0 {
+			index = len(parts) + index
+		}
+
+		if index < 0 || index >= len(parts) {
+			return ""
+		}
+		return parts[index]
+	}), nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// urlparse: picks one part of an url (or a query parameter)
+
+func newUrlParseFieldFilter(config *MetricFieldFilter) (FieldFilter, error) {
+	opts := struct {
+		Part      string `json:"part"`
+		Parameter string `json:"parameter"`
+	}{}
+	if err := config.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	part := strings.ToLower(opts.Part)
+	switch part {
+	case "":
+		part = FieldFilterUrlPartHost
+	case FieldFilterUrlPartScheme, FieldFilterUrlPartUser, FieldFilterUrlPartHost, FieldFilterUrlPartHostname,
+		FieldFilterUrlPartPort, FieldFilterUrlPartPath, FieldFilterUrlPartQuery, FieldFilterUrlPartFragment:
+	default:
+		return nil, fmt.Errorf("unsupported part \"%v\"", opts.Part)
+	}
+
+	if opts.Parameter != "" && part != FieldFilterUrlPartQuery {
+		return nil, fmt.Errorf("parameter needs part \"%v\"", FieldFilterUrlPartQuery)
+	}
+
+	return FieldFilterFunc(func(value string) string {
+		parsedUrl, err := url.Parse(value)
+		if err != nil {
+			return ""
+		}
+
+		switch part {
+		case FieldFilterUrlPartScheme:
+			return parsedUrl.Scheme
+		case FieldFilterUrlPartUser:
+			return parsedUrl.User.Username()
+		case FieldFilterUrlPartHostname:
+			return parsedUrl.Hostname()
+		case FieldFilterUrlPartPort:
+			return parsedUrl.Port()
+		case FieldFilterUrlPartPath:
+			return parsedUrl.Path
+		case FieldFilterUrlPartQuery:
+			if opts.Parameter != "" {
+				return parsedUrl.Query().Get(opts.Parameter)
+			}
+			return parsedUrl.RawQuery
+		case FieldFilterUrlPartFragment:
+			return parsedUrl.Fragment
+		default:
+			return parsedUrl.Host
+		}
+	}), nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// jsonpath: picks a value from a json string (eg. dynamic column) using a path like "properties.tags[0].name"
+
+func newJsonPathFieldFilter(config *MetricFieldFilter) (FieldFilter, error) {
+	opts := struct {
+		Path string `json:"path"`
+	}{}
+	if err := config.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	path := jsonPathRegexp.FindAllString(strings.TrimPrefix(strings.TrimPrefix(opts.Path, "$"), "."), -1)
+	if len(path) == 0 {
+		return nil, errors.New("no path set")
+	}
+
+	return FieldFilterFunc(func(value string) string {
+		var data interface{}
+		if err := json.Unmarshal([]byte(value), &data); err != nil {
+			return ""
+		}
+
+		for _, element := range path {
+			switch v := data.(type) {
+			case map[string]interface{}:
+				data = v[element]
+			case []interface{}:
+				index, err := strconv.Atoi(strings.Trim(element, "[]"))
+				if err != nil || index < 0 || index >= len(v) {
+					return ""
+				}
+				data = v[index]
+			default:
+				return ""
+			}
+		}
+
+		switch v := data.(type) {
+		case nil:
+			return ""
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			return strconv.FormatBool(v)
+		default:
+			ret, _ := json.Marshal(v)
+			return string(ret)
+		}
+	}), nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// durationtoseconds
+
+// convertDurationToSeconds converts Go (1h30m), ISO8601 (PT1H30M) and kusto timespan (1.02:30:00) durations to seconds
+func convertDurationToSeconds(value string) string {
+	value = strings.TrimSpace(value)
+
+	if duration, err := time.ParseDuration(value); err == nil {
+		return strconv.FormatFloat(duration.Seconds(), 'f', -1, 64)
+	}
+
+	if match := iso8601DurationRegexp.FindStringSubmatch(strings.ToUpper(value)); match != nil && len(value) > 2 {
+		seconds := float64(0)
+		for i, factor := range []float64{7 * 86400, 86400, 3600, 60, 1} {
+			if match[i+2] != "" {
+				val, _ := strconv.ParseFloat(match[i+2], 64)
+				seconds += val * factor
+			}
+		}
+		if match[1] != "" {
+			seconds = -seconds
+		}
+		return strconv.FormatFloat(seconds, 'f', -1, 64)
+	}
+
+	if match := timespanRegexp.FindStringSubmatch(value); match != nil {
+		days, _ := strconv.ParseFloat(match[2], 64)
+		hours, _ := strconv.ParseFloat(match[3], 64)
+		minutes, _ := strconv.ParseFloat(match[4], 64)
+		seconds, _ := strconv.ParseFloat(match[5], 64)
+		seconds += days*86400 + hours*3600 + minutes*60
+		if match[1] != "" {
+			seconds = -seconds
+		}
+		return strconv.FormatFloat(seconds, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+// ------------------------------------------------------------------------------------------------
+// bytestonumber
+
+// convertBytesToNumber converts human readable sizes (eg. 1.5 GiB, 10MB) to bytes
+func convertBytesToNumber(value string) string {
+	bytes, err := humanize.ParseBytes(strings.TrimSpace(value))
+	if err != nil {
+		return ""
+	}
+	return strconv.FormatUint(bytes, 10)
+}
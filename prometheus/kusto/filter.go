@@ -0,0 +1,143 @@
+package kusto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type (
+	// FieldFilter transforms the (string) value of a field
+	FieldFilter interface {
+		Transform(value string) string
+	}
+
+	// FieldFilterFunc is a FieldFilter without configuration
+	FieldFilterFunc func(value string) string
+
+	// FieldFilterFactory creates the filter from the filter configuration, returns an error if the configuration is invalid
+	FieldFilterFactory func(config *MetricFieldFilter) (FieldFilter, error)
+)
+
+var (
+	fieldFilterRegistry    = map[string]FieldFilterFactory{}
+	fieldFilterRegistryMux sync.RWMutex
+)
+
+func init() {
+	RegisterFieldFilter(MetricFieldFilterToLower, newStaticFieldFilter(strings.ToLower))
+	RegisterFieldFilter(MetricFieldFilterToUpper, newStaticFieldFilter(strings.ToUpper))
+	RegisterFieldFilter(MetricFieldFilterToTitle, newStaticFieldFilter(strings.ToTitle))
+	RegisterFieldFilter(MetricFieldFilterToUnixtime, newStaticFieldFilter(convertStringToUnixtime))
+	RegisterFieldFilter(MetricFieldFilterToRegexp, newRegexpFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterTrim, newTrimFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterDefault, newDefaultFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterTruncate, newTruncateFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterHash, newHashFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterMap, newMapFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterLookup, newMapFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterSplit, newSplitFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterUrlParse, newUrlParseFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterJsonPath, newJsonPathFieldFilter)
+	RegisterFieldFilter(MetricFieldFilterDurationToSeconds, newStaticFieldFilter(convertDurationToSeconds))
+	RegisterFieldFilter(MetricFieldFilterBytesToNumber, newStaticFieldFilter(convertBytesToNumber))
+}
+
+// RegisterFieldFilter registers a field filter which can be used in the filters of fields (name is case insensitive)
+//
+//	panics if the name is empty or already registered
+func RegisterFieldFilter(name string, factory FieldFilterFactory) {
+	name = strings.ToLower(name)
+	if name == "" || factory == nil {
+		panic(`field filter needs name and factory`)
+	}
+
+	fieldFilterRegistryMux.Lock()
+	defer fieldFilterRegistryMux.Unlock()
+
+	if _, exists := fieldFilterRegistry[name]; exists {
+		panic(fmt.Sprintf(`field filter "%v" is already registered`, name))
+	}
+	fieldFilterRegistry[name] = factory
+}
+
+// GetFieldFilterNames returns the names of all registered field filters
+func GetFieldFilterNames() []string {
+	fieldFilterRegistryMux.RLock()
+	defer fieldFilterRegistryMux.RUnlock()
+	return slices.Sorted(maps.Keys(fieldFilterRegistry))
+}
+
+func (f FieldFilterFunc) Transform(value string) string {
+	return f(value)
+}
+
+// newStaticFieldFilter creates a factory for filters without configuration
+func newStaticFieldFilter(transform func(value string) string) FieldFilterFactory {
+	return func(config *MetricFieldFilter) (FieldFilter, error) {
+		if err := config.DecodeConfig(&struct{}{}); err != nil {
+			return nil, err
+		}
+		return FieldFilterFunc(transform), nil
+	}
+}
+
+// buildFilter creates the filter using the registered factory
+func (f *MetricFieldFilter) buildFilter() (FieldFilter, error) {
+	if f.Type == "" {
+		return nil, errors.New("no type name set")
+	}
+
+	fieldFilterRegistryMux.RLock()
+	factory, exists := fieldFilterRegistry[strings.ToLower(f.Type)]
+	fieldFilterRegistryMux.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("filter \"%v\" not supported", f.Type)
+	}
+
+	filter, err := factory(f)
+	if err != nil {
+		return nil, fmt.Errorf("filter \"%v\": %w", f.Type, err)
+	}
+	return filter, nil
+}
+
+// Transform applies the filter to the value (values are not changed if the filter is invalid)
+func (f *MetricFieldFilter) Transform(value string) string {
+	if f.filter == nil {
+		filter, err := f.buildFilter()
+		if err != nil {
+			return value
+		}
+		f.filter = filter
+	}
+
+	return f.filter.Transform(value)
+}
+
+// DecodeConfig decodes the filter configuration (without type) into v, unknown keys are rejected
+func (f *MetricFieldFilter) DecodeConfig(v interface{}) error {
+	if len(f.raw) == 0 {
+		return nil
+	}
+
+	config := map[string]json.RawMessage{}
+	if err := json.Unmarshal(f.raw, &config); err != nil {
+		return err
+	}
+	delete(config, "type")
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
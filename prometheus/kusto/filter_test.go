@@ -0,0 +1,115 @@
+package kusto
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func newTestFilterField(t *testing.T, filters string) MetricField {
+	t.Helper()
+
+	field := MetricField{}
+	if err := yaml.Unmarshal([]byte("name: test\nfilters:\n"+filters), &field); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := field.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return field
+}
+
+func Test_FieldFilters(t *testing.T) {
+	tests := []struct {
+		filters  string
+		value    string
+		expected string
+	}{
+		{"- tounixtime", "2023-01-02T03:04:05Z", "1672628645"},
+		{"- {type: regexp, regexp: '^/subscriptions/([^/]+)/.*', replacement: '$1'}", "/subscriptions/foo/resourceGroups/bar", "foo"},
+		{"- trim\n- toupper", "  westeurope ", "WESTEUROPE"},
+		{"- {type: trim, chars: '/'}", "/foo/", "foo"},
+		{"- {type: default, value: unknown}", " ", "unknown"},
+		{"- {type: default, value: unknown}", "foo", "foo"},
+		{"- {type: truncate, length: 3}", "westeurope", "wes"},
+		{"- {type: hash, length: 8}", "foo", "2c26b46b"},
+		{"- {type: hash, algorithm: sha1}", "foo", "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33"},
+		{"- {type: map, values: {Succeeded: ok}, default: failed, ignoreCase: true}", "succeeded", "ok"},
+		{"- {type: lookup, values: {Succeeded: ok}, default: failed}", "Canceled", "failed"},
+		{"- {type: lookup, values: {Succeeded: ok}}", "Canceled", "Canceled"},
+		{"- {type: split, separator: '/', index: -1}", "/subscriptions/foo/resourceGroups/bar", "bar"},
+		{"- {type: split, separator: '/', index: 10}", "/subscriptions/foo", ""},
+		{"- {type: urlparse}", "https://example.com:8443/foo?bar=baz", "example.com:8443"},
+		{"- {type: urlparse, part: hostname}", "https://example.com:8443/foo?bar=baz", "example.com"},
+		{"- {type: urlparse, part: query, parameter: bar}", "https://example.com:8443/foo?bar=baz", "baz"},
+		{"- {type: jsonpath, path: 'properties.tags[1].name'}", `{"properties":{"tags":[{"name":"a"},{"name":"b"}]}}`, "b"},
+		{"- {type: jsonpath, path: '$.count'}", `{"count":12.5}`, "12.5"},
+		{"- {type: jsonpath, path: 'missing.value'}", `{"count":12.5}`, ""},
+		{"- durationtoseconds", "1h30m", "5400"},
+		{"- durationtoseconds", "PT1H30M", "5400"},
+		{"- durationtoseconds", "P1DT1S", "86401"},
+		{"- durationtoseconds", "1.02:00:00.5", "93600.5"},
+		{"- durationtoseconds", "invalid", ""},
+		{"- bytestonumber", "1.5 KiB", "1536"},
+		{"- bytestonumber", "10MB", "10000000"},
+	}
+
+	for _, test := range tests {
+		field := newTestFilterField(t, test.filters)
+		if actual := field.TransformString(test.value); actual != test.expected {
+			t.Errorf("filter %v: expected %q for %q, got %q", test.filters, test.expected, test.value, actual)
+		}
+	}
+}
+
+func Test_FieldFilterValidation(t *testing.T) {
+	tests := map[string]string{
+		"- unknown":                           `filter "unknown" not supported`,
+		"- {type: truncate}":                  `filter "truncate": length must be greater than zero`,
+		"- {type: truncate, length: 3, x: 1}": `filter "truncate": json: unknown field "x"`,
+		"- {type: hash, algorithm: md5}":      `filter "hash": unsupported algorithm "md5"`,
+		"- {type: split}":                     `filter "split": no separator set`,
+		"- {type: urlparse, part: foo}":       `filter "urlparse": unsupported part "foo"`,
+		"- {type: tolower, regexp: foo}":      `filter "tolower": json: unknown field "regexp"`,
+	}
+
+	for filters, expected := range tests {
+		field := MetricField{}
+		if err := yaml.Unmarshal([]byte("name: test\nfilters:\n"+filters), &field); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := field.Validate(); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("filter %v: expected error %q, got %v", filters, expected, err)
+		}
+	}
+}
+
+func Test_CustomFieldFilter(t *testing.T) {
+	RegisterFieldFilter("testprefix", func(config *MetricFieldFilter) (FieldFilter, error) {
+		opts := struct {
+			Prefix string `json:"prefix"`
+		}{}
+		if err := config.DecodeConfig(&opts); err != nil {
+			return nil, err
+		}
+
+		return FieldFilterFunc(func(value string) string {
+			return opts.Prefix + value
+		}), nil
+	})
+
+	field := newTestFilterField(t, "- {type: TestPrefix, prefix: 'azure-'}")
+	if actual := field.TransformString("westeurope"); actual != "azure-westeurope" {
+		t.Errorf("expected custom filter to be applied, got %v", actual)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for duplicate filter registration")
+		}
+	}()
+	RegisterFieldFilter(MetricFieldFilterToLower, newStaticFieldFilter(strings.ToLower))
+}
@@ -1,6 +1,12 @@
 package kusto
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 type (
 	MetricList struct {
@@ -8,8 +14,9 @@ type (
 	}
 
 	MetricRow struct {
-		Labels prometheus.Labels
-		Value  *float64
+		Labels    prometheus.Labels
+		Value     *float64
+		Timestamp *time.Time
 	}
 )
 
@@ -46,19 +53,60 @@ func (l *MetricList) GetMetricList(name string) []MetricRow {
 	return l.List[name]
 }
 
+// GetMetricLabelNames returns the union of the label names of all rows of the metric (sorted)
 func (l *MetricList) GetMetricLabelNames(name string) []string {
-	uniqueLabelMap := map[string]string{}
-
+	uniqueLabelMap := map[string]bool{}
 	for _, row := range l.List[name] {
 		for labelName := range row.Labels {
-			uniqueLabelMap[labelName] = labelName
+			uniqueLabelMap[labelName] = true
 		}
 	}
 
-	list := []string{}
-	for labelName := range uniqueLabelMap {
-		list = append(list, labelName)
+	return slices.Sorted(maps.Keys(uniqueLabelMap))
+}
+
+// GetLabelSchema returns the union of the label names of all rows for every metric (sorted)
+func (l *MetricList) GetLabelSchema() map[string][]string {
+	schema := make(map[string][]string, len(l.List))
+	for name := range l.List {
+		schema[name] = l.GetMetricLabelNames(name)
 	}
+	return schema
+}
 
-	return list
+// ApplyLabelSchema sets the labels of all rows to the label names of the schema, missing labels are set
+// to defaultValue and labels which are not part of the schema are removed (metrics without schema are not changed)
+func (l *MetricList) ApplyLabelSchema(schema map[string][]string, defaultValue string) {
+	for name, rows := range l.List {
+		labelNames, exists := schema[name]
+		if !exists {
+			continue
+		}
+
+		for i := range rows {
+			if rows[i].Labels == nil {
+				rows[i].Labels = prometheus.Labels{}
+			}
+
+			for labelName := range rows[i].Labels {
+				if !slices.Contains(labelNames, labelName) {
+					delete(rows[i].Labels, labelName)
+				}
+			}
+
+			for _, labelName := range labelNames {
+				if _, exists := rows[i].Labels[labelName]; !exists {
+					rows[i].Labels[labelName] = defaultValue
+				}
+			}
+		}
+	}
+}
+
+// NormalizeLabels fills missing labels of all rows with defaultValue, so all rows of a metric have the same
+// label names, and returns the label schema (see GetLabelSchema)
+func (l *MetricList) NormalizeLabels(defaultValue string) map[string][]string {
+	schema := l.GetLabelSchema()
+	l.ApplyLabelSchema(schema, defaultValue)
+	return schema
 }
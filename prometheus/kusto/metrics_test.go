@@ -0,0 +1,45 @@
+package kusto
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func Test_MetricListNormalizeLabels(t *testing.T) {
+	list := &MetricList{}
+	list.Init()
+	list.Add("foo",
+		MetricRow{Labels: prometheus.Labels{"resourceId": "/foo", "location": "westeurope"}, Value: toFloat64Ptr(1)},
+		MetricRow{Labels: prometheus.Labels{"resourceId": "/bar"}, Value: toFloat64Ptr(2)},
+		MetricRow{Labels: prometheus.Labels{"resourceId": "/baz", "sku": "basic"}, Value: toFloat64Ptr(3)},
+	)
+	list.Add("bar", MetricRow{Value: toFloat64Ptr(4)})
+
+	schema := list.NormalizeLabels("unknown")
+
+	if labels := schema["foo"]; !slices.Equal(labels, []string{"location", "resourceId", "sku"}) {
+		t.Errorf("unexpected schema: %v", labels)
+	}
+
+	if labels := schema["bar"]; len(labels) != 0 {
+		t.Errorf("unexpected schema: %v", labels)
+	}
+
+	for _, row := range list.GetMetricList("foo") {
+		if len(row.Labels) != 3 {
+			t.Errorf("expected 3 labels, got %v", row.Labels)
+		}
+	}
+
+	if row := list.GetMetricList("foo")[1]; row.Labels["location"] != "unknown" || row.Labels["sku"] != "unknown" || row.Labels["resourceId"] != "/bar" {
+		t.Errorf("unexpected labels: %v", row.Labels)
+	}
+
+	// labels which are not part of the schema are removed
+	list.ApplyLabelSchema(map[string][]string{"foo": {"resourceId"}}, "")
+	if row := list.GetMetricList("foo")[0]; len(row.Labels) != 1 || row.Labels["resourceId"] != "/foo" {
+		t.Errorf("unexpected labels: %v", row.Labels)
+	}
+}
@@ -2,6 +2,7 @@ package kusto
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
@@ -26,14 +27,31 @@ var (
 )
 
 func convertStringToUnixtime(val string) (ret string) {
+	if parseVal := convertStringToTime(val); parseVal != nil {
+		ret = fmt.Sprintf("%v", parseVal.Unix())
+	}
+
+	return
+}
+
+func convertStringToTime(val string) *time.Time {
 	for _, timeFormat := range timeFormats {
 		if parseVal, parseErr := time.Parse(timeFormat, val); parseErr == nil && parseVal.Unix() > 0 {
-			ret = fmt.Sprintf("%v", parseVal.Unix())
-			break
+			return &parseVal
 		}
 	}
 
-	return
+	return nil
+}
+
+func convertUnixtimeToTime(val float64) *time.Time {
+	if val <= 0 {
+		return nil
+	}
+
+	sec, frac := math.Modf(val)
+	ret := time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	return &ret
 }
 
 func toFloat64Ptr(val float64) *float64 {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/yaml"
@@ -147,6 +148,45 @@ defaultField:
 	metricTestSuite.metric("azure_testing").row(0).assertValue(20)
 }
 
+func TestMetricRowParsingTimestamp(t *testing.T) {
+	resultRow := parseResourceGraphJsonToResultRow(t, `{
+"name": "foobar",
+"count_": 20,
+"valueA": 13,
+"TimeGenerated": "2024-01-02T03:04:05Z"
+}`)
+
+	queryConfig := parseMetricConfig(t, `
+metric: azure_testing
+fields:
+- name: name
+  target: id
+  type: id
+
+- name: count_
+  type: value
+
+- name: valueA
+  metric: azure_testing_value
+  type: value
+
+- name: TimeGenerated
+  type: timestamp
+
+defaultField:
+  type: ignore
+`)
+
+	metricList := BuildPrometheusMetricList(queryConfig.Metric, *queryConfig.QueryMetric, resultRow)
+
+	metricTestSuite := testingMetricResult{t: t, list: metricList}
+	metricTestSuite.assertMetricNames(2)
+
+	metricTestSuite.metric("azure_testing").row(0).assertLabels("id")
+	metricTestSuite.metric("azure_testing").row(0).assertTimestamp(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	metricTestSuite.metric("azure_testing_value").row(0).assertTimestamp(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+}
+
 func TestMetricRowParsingWithSubMetrics(t *testing.T) {
 	resultRow := parseResourceGraphJsonToResultRow(t, `{
 "name": "foobar",
@@ -562,7 +602,7 @@ defaultField:
 	}
 }
 
-func parseResourceGraphJsonToResultRow(t *testing.T, data string) map[string]interface{} {
+func parseResourceGraphJsonToResultRow(t testing.TB, data string) map[string]interface{} {
 	t.Helper()
 	ret := map[string]interface{}{}
 	if err := json.Unmarshal([]byte(data), &ret); err != nil {
@@ -571,7 +611,7 @@ func parseResourceGraphJsonToResultRow(t *testing.T, data string) map[string]int
 	return ret
 }
 
-func parseMetricConfig(t *testing.T, data string) Query {
+func parseMetricConfig(t testing.TB, data string) Query {
 	t.Helper()
 	ret := Query{}
 	if err := yaml.UnmarshalStrict([]byte(data), &ret); err != nil {
@@ -670,6 +710,18 @@ func (m *testingMetricRow) assertValue(metricValue float64) {
 	}
 }
 
+func (m *testingMetricRow) assertTimestamp(timestamp time.Time) {
+	m.t.Helper()
+
+	if val := m.row.Timestamp; val == nil {
+		m.t.Fatalf(`metric row "%v" has wrong timestamp; expected: "%v", got: "%v"`, m.name, timestamp, "<nil>")
+	}
+
+	if val := m.row.Timestamp; !val.Equal(timestamp) {
+		m.t.Fatalf(`metric row "%v" has wrong timestamp; expected: "%v", got: "%v"`, m.name, timestamp, *val)
+	}
+}
+
 func (m *testingMetricRow) assertNilValue() {
 	m.t.Helper()
 	if val := m.row.Value; val != nil {
@@ -0,0 +1,221 @@
+package kusto
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type (
+	// QueryPlan is a compiled QueryMetric configuration, the field configuration is only analysed once
+	// and the plan can be reused for all rows of a query result (see Process)
+	QueryPlan struct {
+		name   string
+		config *QueryMetric
+
+		defaultField *MetricField
+		columns      map[string]*queryPlanColumn
+		expands      []*queryPlanExpand
+
+		// all metric names which can be built by the plan (including sub plans)
+		metricNames []string
+	}
+
+	queryPlanColumn struct {
+		fields []*queryPlanField
+	}
+
+	queryPlanField struct {
+		*MetricField
+		isId bool
+	}
+
+	queryPlanExpand struct {
+		column string
+		plans  []*QueryPlan
+	}
+
+	queryPlanIdLabel struct {
+		name  string
+		value string
+	}
+)
+
+// NewQueryPlan compiles the metric configuration into a reusable plan
+func NewQueryPlan(name string, metricConfig QueryMetric) *QueryPlan {
+	p := &QueryPlan{
+		name:        name,
+		config:      &metricConfig,
+		columns:     map[string]*queryPlanColumn{},
+		expands:     []*queryPlanExpand{},
+		metricNames: []string{name},
+	}
+
+	if !metricConfig.DefaultField.IsTypeIgnore() {
+		p.defaultField = &metricConfig.DefaultField
+	}
+
+	fieldConfigMap := metricConfig.GetFieldConfigMap()
+	for columnName, fieldConfList := range fieldConfigMap {
+		// columns with field configuration are never processed by the default field
+		// (even if all fields of the column are ignored or expanded)
+		column := &queryPlanColumn{fields: []*queryPlanField{}}
+		p.columns[columnName] = column
+
+		for i := range fieldConfList {
+			fieldConfig := &fieldConfList[i]
+			if fieldConfig.IsTypeIgnore() || fieldConfig.IsExpand() {
+				continue
+			}
+
+			column.fields = append(column.fields, &queryPlanField{MetricField: fieldConfig, isId: fieldConfig.IsTypeId()})
+			if fieldConfig.Metric != "" {
+				p.metricNames = append(p.metricNames, fieldConfig.Metric)
+			}
+		}
+
+		// sub metrics (aka nested/expand structures)
+		if !metricConfig.IsExpand(columnName) {
+			continue
+		}
+
+		expand := &queryPlanExpand{column: columnName, plans: []*QueryPlan{}}
+		for _, fieldConfig := range fieldConfList {
+			if fieldConfig.IsTypeIgnore() {
+				continue
+			}
+
+			// add fieldname to metric if no custom metric is set
+			subMetricName := fieldConfig.Metric
+			if subMetricName == "" {
+				subMetricName = fmt.Sprintf("%s_%s", name, columnName)
+			}
+
+			subMetricConfig := QueryMetric{}
+			if fieldConfig.Expand != nil {
+				subMetricConfig = *fieldConfig.Expand
+			}
+
+			subPlan := NewQueryPlan(subMetricName, subMetricConfig)
+			expand.plans = append(expand.plans, subPlan)
+			p.metricNames = append(p.metricNames, subPlan.metricNames...)
+		}
+
+		if len(expand.plans) > 0 {
+			p.expands = append(p.expands, expand)
+		}
+	}
+
+	slices.SortFunc(p.expands, func(a, b *queryPlanExpand) int {
+		return strings.Compare(a.column, b.column)
+	})
+	slices.Sort(p.metricNames)
+	p.metricNames = slices.Compact(p.metricNames)
+
+	return p
+}
+
+// Process builds the metrics of the row (same as BuildPrometheusMetricList) and appends them to the list
+func (p *QueryPlan) Process(row map[string]interface{}, list *MetricList) {
+	if list.List == nil {
+		list.Init()
+	}
+
+	// remember the existing rows, id labels and timestamp are only added to the rows of this row
+	start := make([]int, len(p.metricNames))
+	for i, metricName := range p.metricNames {
+		start[i] = len(list.List[metricName])
+	}
+
+	mainMetric := NewMetricRow()
+
+	// add default value to main metric (if set)
+	if p.config.Value != nil {
+		mainMetric.Value = p.config.Value
+	}
+
+	// additional labels
+	for labelName, labelValue := range p.config.Labels {
+		mainMetric.Labels[labelName] = labelValue
+	}
+
+	// main metric
+	var idLabels []queryPlanIdLabel
+	for fieldName, rowValue := range row {
+		column, exists := p.columns[fieldName]
+		if !exists {
+			// no field config, fall back to "defaultField"
+			if p.defaultField != nil {
+				processFieldAndAddToMetric(fieldName, rowValue, p.defaultField, mainMetric)
+			}
+			continue
+		}
+
+		for _, fieldConfig := range column.fields {
+			metricRow := mainMetric
+			if fieldConfig.Metric != "" {
+				// field has own metric name, assuming individual metric row
+				metricRow = NewMetricRow()
+			}
+
+			processFieldAndAddToMetric(fieldName, rowValue, fieldConfig.MetricField, metricRow)
+
+			// additional labels
+			for labelName, labelValue := range fieldConfig.Labels {
+				metricRow.Labels[labelName] = labelValue
+			}
+
+			// id labels
+			if fieldConfig.isId {
+				labelName := fieldConfig.GetTargetFieldName(fieldName)
+				if _, ok := mainMetric.Labels[labelName]; ok {
+					idLabels = append(idLabels, queryPlanIdLabel{name: labelName, value: metricRow.Labels[labelName]})
+				}
+			}
+
+			if fieldConfig.Metric != "" {
+				list.Add(fieldConfig.Metric, *metricRow)
+			}
+		}
+	}
+
+	// sub metrics (aka nested/expand structures)
+	for _, expand := range p.expands {
+		rowValue, exists := row[expand.column]
+		if !exists {
+			continue
+		}
+
+		for _, rowValue := range convertSubMetricInterfaceToArray(rowValue) {
+			if v, ok := rowValue.(map[string]interface{}); ok {
+				for _, subPlan := range expand.plans {
+					subPlan.Process(v, list)
+				}
+			}
+		}
+	}
+
+	// add main metric
+	if p.config.IsPublished() {
+		list.Add(p.name, *mainMetric)
+	}
+
+	// add id labels and timestamp of main metric
+	for i, metricName := range p.metricNames {
+		metricRows := list.List[metricName]
+		for j := start[i]; j < len(metricRows); j++ {
+			for _, idLabel := range idLabels {
+				metricRows[j].Labels[idLabel.name] = idLabel.value
+			}
+
+			if metricRows[j].Timestamp == nil {
+				metricRows[j].Timestamp = mainMetric.Timestamp
+			}
+		}
+	}
+}
+
+// GetMetricNames returns all metric names which can be built by the plan
+func (p *QueryPlan) GetMetricNames() []string {
+	return slices.Clone(p.metricNames)
+}
@@ -0,0 +1,150 @@
+package kusto
+
+import (
+	"fmt"
+	"testing"
+)
+
+const (
+	testPlanConfig = `
+metric: azurerm_managedclusters_aks_info
+query: Resources
+value: 1
+fields:
+  - name: id
+    target: resourceID
+    type: id
+  - name: name
+    target: cluster
+  - name: subscriptionId
+    target: subscriptionID
+  - name: location
+    filters: [toupper]
+  - name: type
+    target: provider
+  - name: kubernetesVersion
+  - name: nodeCount
+    metric: azurerm_managedclusters_aks_nodes
+    type: value
+  - name: tags
+    metric: azurerm_managedclusters_tags
+    expand:
+      value: 1
+      fields:
+        - name: owner
+        - name: domain
+      defaultField:
+        type: ignore
+  - name: agentPoolProfiles
+    metric: azurerm_managedclusters_aks_pool
+    expand:
+      value: 1
+      fields:
+        - name: name
+          target: pool
+          type: id
+        - name: osType
+        - name: vmSize
+        - name: count
+          metric: azurerm_managedclusters_aks_pool_size
+          type: value
+      defaultField:
+        type: ignore
+defaultField:
+  type: ignore
+`
+
+	testPlanRow = `{
+	"id": "/subscriptions/xxx/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/cluster%[1]d",
+	"name": "cluster%[1]d",
+	"type": "microsoft.containerservice/managedclusters",
+	"subscriptionId": "xxx",
+	"location": "westeurope",
+	"kubernetesVersion": "1.21.2",
+	"nodeCount": %[1]d,
+	"sku": {"name": "Basic", "tier": "Free"},
+	"tags": {"owner": "team-xzy", "domain": "kubernetes"},
+	"agentPoolProfiles": [
+		{"name": "agents", "osType": "Linux", "vmSize": "Standard_B2s", "count": 3, "mode": "System"},
+		{"name": "nodepool1", "osType": "Linux", "vmSize": "Standard_DS2_v2", "count": 12, "mode": "User"}
+	]
+}`
+)
+
+func newTestPlanRows(t testing.TB, count int) []map[string]interface{} {
+	t.Helper()
+
+	rows := make([]map[string]interface{}, count)
+	for i := range rows {
+		rows[i] = parseResourceGraphJsonToResultRow(t, fmt.Sprintf(testPlanRow, i))
+	}
+	return rows
+}
+
+func Test_QueryPlan(t *testing.T) {
+	queryConfig := parseMetricConfig(t, testPlanConfig)
+	rows := newTestPlanRows(t, 3)
+
+	plan := NewQueryPlan(queryConfig.Metric, *queryConfig.QueryMetric)
+
+	result := &MetricList{}
+	result.Init()
+	for _, row := range rows {
+		plan.Process(row, result)
+	}
+
+	expected := map[string]int{
+		"azurerm_managedclusters_aks_info":      3,
+		"azurerm_managedclusters_aks_nodes":     3,
+		"azurerm_managedclusters_tags":          3,
+		"azurerm_managedclusters_aks_pool":      6,
+		"azurerm_managedclusters_aks_pool_size": 6,
+	}
+	if len(result.List) != len(expected) {
+		t.Errorf("expected metrics %v, got %v", expected, result.GetMetricNames())
+	}
+	for metricName, count := range expected {
+		if val := len(result.GetMetricList(metricName)); val != count {
+			t.Errorf("metric %v: expected %v rows, got %v", metricName, count, val)
+		}
+	}
+
+	// id labels of the main metric are added to field and sub metrics
+	row := result.GetMetricList("azurerm_managedclusters_aks_pool_size")[5]
+	if row.Labels["resourceID"] != "/subscriptions/xxx/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/cluster2" || row.Labels["pool"] != "nodepool1" || *row.Value != 12 {
+		t.Errorf("unexpected pool size metric: %v", row)
+	}
+}
+
+func BenchmarkBuildPrometheusMetricList(b *testing.B) {
+	queryConfig := parseMetricConfig(b, testPlanConfig)
+	rows := newTestPlanRows(b, 1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		result := &MetricList{}
+		result.Init()
+		for _, row := range rows {
+			for metricName, metricRows := range BuildPrometheusMetricList(queryConfig.Metric, *queryConfig.QueryMetric, row) {
+				result.Add(metricName, metricRows...)
+			}
+		}
+	}
+}
+
+func BenchmarkQueryPlan(b *testing.B) {
+	queryConfig := parseMetricConfig(b, testPlanConfig)
+	rows := newTestPlanRows(b, 1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		plan := NewQueryPlan(queryConfig.Metric, *queryConfig.QueryMetric)
+		result := &MetricList{}
+		result.Init()
+		for _, row := range rows {
+			plan.Process(row, result)
+		}
+	}
+}
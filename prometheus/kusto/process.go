@@ -2,6 +2,7 @@ package kusto
 
 import (
 	"strconv"
+	"time"
 )
 
 func convertSubMetricInterfaceToArray(val interface{}) []interface{} {
@@ -17,7 +18,7 @@ func convertSubMetricInterfaceToArray(val interface{}) []interface{} {
 	return ret
 }
 
-func processFieldAndAddToMetric(fieldName string, value interface{}, fieldConfig MetricField, metric *MetricRow) {
+func processFieldAndAddToMetric(fieldName string, value interface{}, fieldConfig *MetricField, metric *MetricRow) {
 	labelName := fieldConfig.GetTargetFieldName(fieldName)
 
 	// upgrade to 64bit
@@ -42,6 +43,24 @@ func processFieldAndAddToMetric(fieldName string, value interface{}, fieldConfig
 		value = float64(v)
 	}
 
+	if fieldConfig.IsTypeTimestamp() {
+		switch v := value.(type) {
+		case string:
+			metric.Timestamp = convertStringToTime(fieldConfig.TransformString(v))
+		case time.Time:
+			metric.Timestamp = &v
+		case int64:
+			metric.Timestamp = convertUnixtimeToTime(float64(v))
+		case uint64:
+			metric.Timestamp = convertUnixtimeToTime(float64(v))
+		case float64:
+			metric.Timestamp = convertUnixtimeToTime(v)
+		default:
+			metric.Timestamp = nil
+		}
+		return
+	}
+
 	switch v := value.(type) {
 	// ----------------------------------------------------
 	// string
@@ -97,13 +116,23 @@ func processFieldAndAddToMetric(fieldName string, value interface{}, fieldConfig
 			metric.Labels[labelName] = fieldValue
 		}
 
+	// ----------------------------------------------------
+	// datetime
+	case time.Time:
+		if fieldConfig.IsTypeValue() {
+			metric.Value = toFloat64Ptr(float64(v.UnixNano()) / 1e9)
+		} else {
+			metric.Labels[labelName] = fieldConfig.TransformString(v.UTC().Format(time.RFC3339Nano))
+		}
+
 	// ----------------------------------------------------
 	// nil
 	case nil:
 		if fieldConfig.IsTypeValue() {
 			metric.Value = nil
 		} else {
-			metric.Labels[labelName] = ""
+			// filters can set values for empty fields (eg. default filter)
+			metric.Labels[labelName] = fieldConfig.TransformString("")
 		}
 	}
 }
@@ -0,0 +1,288 @@
+package kusto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+	"github.com/webdevops/go-common/prometheus/collector"
+)
+
+type (
+	// QueryExecutorFunc executes the query and returns the result rows
+	//
+	//	rows which are returned together with an error (eg. partial errors) are still processed
+	QueryExecutorFunc func(ctx context.Context, query Query) ([]map[string]interface{}, error)
+
+	// Processor is a collector processor which executes all queries of a kusto config and publishes the results
+	Processor struct {
+		collector.Processor
+
+		config   Config
+		executor QueryExecutorFunc
+
+		schema   map[string][]string
+		metadata map[string]MetricMetadata
+		queries  []*processorQuery
+	}
+
+	processorQuery struct {
+		Query
+		plan     *QueryPlan
+		interval time.Duration
+		lastRun  *time.Time
+		result   *MetricList
+	}
+)
+
+// NewProcessor creates a collector processor for the kusto config
+//
+//	a metric list (by metric type, see Setup) is registered for every metric of the queries, so the label names
+//	of all metrics have to be known from the config (see QueryMetric.GetMetricLabelNames)
+func NewProcessor(config Config, executor QueryExecutorFunc) (*Processor, error) {
+	config.Queries = slices.Clone(config.Queries)
+	for i := range config.Queries {
+		if config.Queries[i].QueryMetric == nil {
+			config.Queries[i].QueryMetric = &QueryMetric{}
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &Processor{
+		config:   config,
+		executor: executor,
+		schema:   map[string][]string{},
+		metadata: map[string]MetricMetadata{},
+		queries:  []*processorQuery{},
+	}
+
+	for _, queryConfig := range config.Queries {
+		if queryConfig.Metric == "" {
+			return nil, errors.New("query without metric name found")
+		}
+
+		metricLabelNames, err := queryConfig.GetMetricLabelNames(queryConfig.Metric)
+		if err != nil {
+			return nil, fmt.Errorf(`query "%v": %w`, queryConfig.Metric, err)
+		}
+
+		for metricName, labelNames := range metricLabelNames {
+			p.schema[metricName] = slices.Compact(slices.Sorted(slices.Values(append(p.schema[metricName], labelNames...))))
+		}
+
+		metricMetadata, err := queryConfig.GetMetricMetadata(queryConfig.Metric)
+		if err != nil {
+			return nil, fmt.Errorf(`query "%v": %w`, queryConfig.Metric, err)
+		}
+
+		for metricName, metadata := range metricMetadata {
+			if p.metadata[metricName], err = mergeMetricMetadata(metricName, p.metadata[metricName], metadata); err != nil {
+				return nil, fmt.Errorf(`query "%v": %w`, queryConfig.Metric, err)
+			}
+		}
+
+		interval, err := queryConfig.GetInterval()
+		if err != nil {
+			return nil, fmt.Errorf(`query "%v": %w`, queryConfig.Metric, err)
+		}
+
+		p.queries = append(p.queries, &processorQuery{
+			Query:    queryConfig,
+			plan:     NewQueryPlan(queryConfig.Metric, *queryConfig.QueryMetric),
+			interval: interval,
+		})
+	}
+
+	return p, nil
+}
+
+// Setup registers the metric lists of all metrics by the metric type (gauge if not set)
+//
+//	gauges, counters and info metrics are exported as const metrics, so the timestamp of the rows is kept,
+//	histograms are observed by a vec (without timestamps)
+func (p *Processor) Setup(collector *collector.Collector) {
+	p.Processor.Setup(collector)
+
+	for _, metricName := range slices.Sorted(maps.Keys(p.schema)) {
+		metadata := p.metadata[metricName]
+		p.Logger().Debug(
+			"registering kusto metric",
+			slog.String("metric", metricName),
+			slog.String("type", metadata.GetMetricType()),
+			slog.Any("labels", p.schema[metricName]),
+		)
+
+		switch metadata.GetMetricType() {
+		case MetricTypeHistogram:
+			p.Collector.RegisterMetricList(metricName, newHistogramVec(metricName, metadata, p.schema[metricName]), true)
+		case MetricTypeCounter:
+			// const metrics must be unique, duplicate rows are summed up (same as added to a counter vec)
+			metricList := p.Collector.RegisterMetricListCollector(metricName, newMetricDesc(metricName, metadata, p.schema[metricName]))
+			metricList.SetDuplicatePolicy(prometheusCommon.DuplicatePolicySum)
+		default:
+			// const metrics must be unique, the last duplicate row is used (same as set to a gauge vec)
+			metricList := p.Collector.RegisterMetricListCollector(metricName, newMetricDesc(metricName, metadata, p.schema[metricName]))
+			metricList.SetDuplicatePolicy(prometheusCommon.DuplicatePolicyLastWins)
+		}
+	}
+}
+
+// newMetricDesc creates the description of the metric for the metric type (info metrics are gauges)
+func newMetricDesc(name string, metadata MetricMetadata, labelNames []string) prometheusCommon.MetricDesc {
+	desc := prometheusCommon.MetricDesc{
+		Name:       name,
+		Help:       metricHelp(name, metadata),
+		LabelNames: labelNames,
+		ValueType:  prometheus.GaugeValue,
+	}
+
+	if metadata.GetMetricType() == MetricTypeCounter {
+		desc.ValueType = prometheus.CounterValue
+	}
+
+	return desc
+}
+
+// newHistogramVec creates the prometheus vec for histogram metrics
+func newHistogramVec(name string, metadata MetricMetadata, labelNames []string) *prometheus.HistogramVec {
+	buckets := metadata.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: metricHelp(name, metadata), Buckets: buckets}, labelNames)
+}
+
+// metricHelp returns the help of the metric (or a generic help if not set)
+func metricHelp(name string, metadata MetricMetadata) string {
+	if metadata.Help != "" {
+		return metadata.Help
+	}
+	return fmt.Sprintf("kusto query result %v", name)
+}
+
+// CacheTag returns a cache tag based on the kusto config, cached metrics are ignored if the config changes
+func (p *Processor) CacheTag() *string {
+	return collector.BuildCacheTag("kusto", p.config)
+}
+
+// GetMetricLabelNames returns the label names of the registered metrics
+func (p *Processor) GetMetricLabelNames() map[string][]string {
+	return maps.Clone(p.schema)
+}
+
+// GetMetricMetadata returns the metadata (type, help, unit, buckets) of the registered metrics
+func (p *Processor) GetMetricMetadata() map[string]MetricMetadata {
+	return maps.Clone(p.metadata)
+}
+
+func (p *Processor) Reset() {}
+
+// Collect executes all queries which are due (in parallel) and republishes the last result of all other queries
+func (p *Processor) Collect(callback chan<- func()) {
+	now := time.Now()
+
+	for _, query := range p.queries {
+		if !query.isDue(now) {
+			p.publish(query.result)
+			continue
+		}
+
+		p.WaitGroup().Add()
+		go func(query *processorQuery) {
+			defer p.WaitGroup().Done()
+			p.collectQuery(query, now)
+		}(query)
+	}
+}
+
+// collectQuery executes the query and publishes the result
+//
+//	errors are reported to the collector, the query is executed again on the next run if no rows were returned
+func (p *Processor) collectQuery(query *processorQuery, now time.Time) {
+	logger := p.Logger().With(slog.String("metric", query.Metric))
+
+	renderedQuery, err := query.RenderQuery()
+	if err != nil {
+		p.Collector.ReportError(err)
+		logger.Warn("unable to render kusto query", slog.Any("error", err))
+		query.result = nil
+		query.lastRun = nil
+		return
+	}
+
+	// parameters are already rendered into the query
+	executedQuery := query.Query
+	executedQuery.Query = renderedQuery
+	executedQuery.Parameters = nil
+	logger.Debug("executing kusto query", slog.String("query", renderedQuery))
+
+	rows, err := p.executor(p.Context(), executedQuery)
+	if err != nil {
+		p.Collector.ReportError(err)
+		logger.Warn("kusto query failed", slog.Any("error", err), slog.Int("rows", len(rows)))
+
+		if len(rows) == 0 {
+			query.result = nil
+			query.lastRun = nil
+			return
+		}
+	}
+
+	result := &MetricList{}
+	result.Init()
+	for _, row := range rows {
+		query.plan.Process(row, result)
+	}
+
+	// vecs need all labels, missing labels are set to the default value
+	result.ApplyLabelSchema(p.schema, query.GetDefaultLabelValue())
+
+	query.result = result
+	query.lastRun = &now
+	p.publish(result)
+}
+
+// publish adds the metric rows of the result to the metric lists, rows without value are skipped (except info metrics)
+//
+//	labels of the result have to match the schema (see MetricList.ApplyLabelSchema)
+func (p *Processor) publish(result *MetricList) {
+	if result == nil {
+		return
+	}
+
+	for metricName, metricRows := range result.List {
+		metricList := p.Collector.GetMetricList(metricName)
+		if _, exists := p.schema[metricName]; metricList == nil || !exists {
+			continue
+		}
+
+		metadata := p.metadata[metricName]
+		isInfo := metadata.GetMetricType() == MetricTypeInfo
+		for _, row := range metricRows {
+			value := row.Value
+			if isInfo {
+				// info metrics always have the value 1
+				value = toFloat64Ptr(1)
+			} else if value == nil {
+				continue
+			}
+
+			metricList.AddRow(prometheusCommon.MetricRow{Labels: row.Labels, Value: *value, Timestamp: row.Timestamp})
+		}
+	}
+}
+
+// isDue returns true if the query has to be executed in the current run
+func (q *processorQuery) isDue(now time.Time) bool {
+	return q.lastRun == nil || q.result == nil || now.Sub(*q.lastRun) >= q.interval
+}
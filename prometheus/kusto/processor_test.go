@@ -0,0 +1,249 @@
+package kusto
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"sigs.k8s.io/yaml"
+
+	"github.com/webdevops/go-common/prometheus/collector"
+)
+
+const testProcessorConfig = `
+queries:
+  - metric: azure_loganalytics_resource_count
+    query: "Heartbeat | summarize count_=count() by resourceId, location, size"
+    interval: 1h
+    labels:
+      source: heartbeat
+    defaultField:
+      type: ignore
+    fields:
+      - name: resourceId
+        type: id
+      - name: location
+        filters: [tolower]
+      - name: count_
+        type: value
+      - name: size
+        metric: azure_loganalytics_resource_size
+        type: value
+        labels:
+          unit: bytes
+`
+
+func newTestProcessorConfig(t *testing.T) Config {
+	t.Helper()
+
+	config := Config{}
+	if err := yaml.Unmarshal([]byte(testProcessorConfig), &config); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func Test_GetMetricLabelNames(t *testing.T) {
+	config := newTestProcessorConfig(t)
+
+	schema, err := config.Queries[0].GetMetricLabelNames(config.Queries[0].Metric)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if labels := schema["azure_loganalytics_resource_count"]; !slices.Equal(labels, []string{"location", "resourceId", "source"}) {
+		t.Errorf("unexpected labels of main metric: %v", labels)
+	}
+
+	if labels := schema["azure_loganalytics_resource_size"]; !slices.Equal(labels, []string{"resourceId", "unit"}) {
+		t.Errorf("unexpected labels of field metric: %v", labels)
+	}
+
+	config.Queries[0].DefaultField.Type = ""
+	if _, err := config.Queries[0].GetMetricLabelNames(config.Queries[0].Metric); err == nil {
+		t.Error("expected error for dynamic labels of defaultField")
+	}
+}
+
+func Test_Processor(t *testing.T) {
+	executions := 0
+	fail := false
+	executor := func(ctx context.Context, query Query) ([]map[string]interface{}, error) {
+		executions++
+		if fail {
+			return nil, errors.New("query failed")
+		}
+		return []map[string]interface{}{
+			{"resourceId": "/foo", "location": "WestEurope", "count_": float64(5), "size": float64(1024)},
+			{"resourceId": "/bar", "location": "NorthEurope", "count_": float64(2), "size": nil},
+			{"resourceId": "/baz", "count_": float64(1)},
+		}, nil
+	}
+
+	defaultLabelValue := "unknown"
+	config := newTestProcessorConfig(t)
+	config.Queries[0].DefaultLabelValue = &defaultLabelValue
+
+	processor, err := NewProcessor(config, executor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := collector.New("kusto_test", processor, slog.New(slog.DiscardHandler))
+
+	now := time.Now()
+	query := processor.queries[0]
+	processor.collectQuery(query, now)
+
+	countRows := c.GetMetricList("azure_loganalytics_resource_count").GetList()
+	if len(countRows) != 3 || countRows[0].Labels["location"] != "westeurope" || countRows[0].Labels["source"] != "heartbeat" || countRows[0].Value != 5 {
+		t.Errorf("unexpected rows: %v", countRows)
+	}
+
+	// missing labels are set to the default label value
+	if len(countRows) == 3 && countRows[2].Labels["location"] != "unknown" {
+		t.Errorf("expected default label value, got %v", countRows[2].Labels)
+	}
+
+	// rows without value are skipped
+	sizeRows := c.GetMetricList("azure_loganalytics_resource_size").GetList()
+	if len(sizeRows) != 1 || sizeRows[0].Labels["resourceId"] != "/foo" || sizeRows[0].Labels["unit"] != "bytes" || sizeRows[0].Value != 1024 {
+		t.Errorf("unexpected rows: %v", sizeRows)
+	}
+
+	if query.isDue(now.Add(30*time.Minute)) || !query.isDue(now.Add(time.Hour)) {
+		t.Error("expected query to be executed once per interval")
+	}
+
+	// failed queries are executed again on next run
+	fail = true
+	processor.collectQuery(query, now)
+	if executions != 2 || !query.isDue(now) {
+		t.Errorf("expected failed query to be due, got %v executions", executions)
+	}
+}
+
+func Test_ProcessorMetricTypes(t *testing.T) {
+	config := Config{}
+	if err := yaml.Unmarshal([]byte(`
+queries:
+  - metric: azure_loganalytics_requests_total
+    metricType: counter
+    help: Requests of the app roles
+    query: "AppRequests | summarize count_=count(), duration=avg(DurationMs) / 1000 by AppRoleName, AppVersion"
+    defaultField:
+      type: ignore
+    fields:
+      - name: AppRoleName
+        type: id
+      - name: count_
+        type: value
+      - name: AppVersion
+        metric: azure_loganalytics_app_info
+        metricType: info
+      - name: duration
+        metric: azure_loganalytics_request_duration_seconds
+        metricType: histogram
+        unit: seconds
+        buckets: [0.1, 1, 10]
+        type: value
+`), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	processor, err := NewProcessor(config, func(ctx context.Context, query Query) ([]map[string]interface{}, error) {
+		return []map[string]interface{}{
+			{"AppRoleName": "frontend", "AppVersion": "1.2.3", "count_": float64(12), "duration": 0.5},
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := processor.GetMetricMetadata()
+	if md := metadata["azure_loganalytics_requests_total"]; md.GetMetricType() != MetricTypeCounter || md.Help != "Requests of the app roles" {
+		t.Errorf("unexpected metadata: %+v", md)
+	}
+	if md := metadata["azure_loganalytics_request_duration_seconds"]; md.GetMetricType() != MetricTypeHistogram || !slices.Equal(md.Buckets, []float64{0.1, 1, 10}) {
+		t.Errorf("unexpected metadata: %+v", md)
+	}
+
+	labelNames := processor.GetMetricLabelNames()
+	if desc := newMetricDesc("azure_loganalytics_requests_total", metadata["azure_loganalytics_requests_total"], labelNames["azure_loganalytics_requests_total"]); desc.ValueType != prometheus.CounterValue || desc.Help != "Requests of the app roles" {
+		t.Errorf("unexpected desc: %+v", desc)
+	}
+	if desc := newMetricDesc("azure_loganalytics_app_info", metadata["azure_loganalytics_app_info"], labelNames["azure_loganalytics_app_info"]); desc.ValueType != prometheus.GaugeValue {
+		t.Errorf("unexpected desc: %+v", desc)
+	}
+
+	c := collector.New("kusto_types_test", processor, slog.New(slog.DiscardHandler))
+	processor.collectQuery(processor.queries[0], time.Now())
+
+	// info metrics always have the value 1
+	infoRows := c.GetMetricList("azure_loganalytics_app_info").GetList()
+	if len(infoRows) != 1 || infoRows[0].Value != 1 || infoRows[0].Labels["AppVersion"] != "1.2.3" || infoRows[0].Labels["AppRoleName"] != "frontend" {
+		t.Errorf("unexpected rows: %v", infoRows)
+	}
+}
+
+func Test_ProcessorTimestamps(t *testing.T) {
+	config := Config{}
+	if err := yaml.Unmarshal([]byte(`
+queries:
+  - metric: azure_loganalytics_heartbeat_count
+    query: "Heartbeat | summarize count_=count(), last=max(TimeGenerated) by Computer"
+    defaultField:
+      type: ignore
+    fields:
+      - name: Computer
+      - name: count_
+        type: value
+      - name: last
+        type: timestamp
+`), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	processor, err := NewProcessor(config, func(ctx context.Context, query Query) ([]map[string]interface{}, error) {
+		return []map[string]interface{}{
+			{"Computer": "vm1", "count_": float64(3), "last": timestamp},
+			{"Computer": "vm1", "count_": float64(4), "last": timestamp},
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// metric lists are registered by collector.New (default registry)
+	c := collector.New("kusto_timestamps_test", processor, slog.New(slog.DiscardHandler))
+	processor.collectQuery(processor.queries[0], time.Now())
+
+	metricList := c.GetMetricList("azure_loganalytics_heartbeat_count")
+	if _, err := metricList.Deduplicate(); err != nil {
+		t.Fatal(err)
+	}
+	metricList.Publish()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := slices.IndexFunc(families, func(family *dto.MetricFamily) bool {
+		return family.GetName() == "azure_loganalytics_heartbeat_count"
+	})
+	if index == -1 || len(families[index].GetMetric()) != 1 {
+		t.Fatalf("expected one series, got %v", families)
+	}
+
+	metric := families[index].GetMetric()[0]
+	if metric.GetTimestampMs() != timestamp.UnixMilli() || metric.GetGauge().GetValue() != 4 {
+		t.Errorf("expected value 4 with timestamp of the row, got %v", metric)
+	}
+}
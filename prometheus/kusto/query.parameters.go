@@ -0,0 +1,245 @@
+package kusto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	QueryParameterTypeString   = "string"
+	QueryParameterTypeLong     = "long"
+	QueryParameterTypeReal     = "real"
+	QueryParameterTypeBool     = "bool"
+	QueryParameterTypeTimespan = "timespan"
+	QueryParameterTypeDatetime = "datetime"
+	QueryParameterTypeDynamic  = "dynamic"
+)
+
+var (
+	queryParameterNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type (
+	// QueryParameter is a parameter of a query, the value is taken from the env var (if set) or from value
+	QueryParameter struct {
+		Name  string      `json:"name"`
+		Type  string      `json:"type"`
+		Value interface{} `json:"value"`
+		Env   string      `json:"env"`
+	}
+)
+
+// GetType returns the kusto type of the parameter (string if not set)
+func (p *QueryParameter) GetType() (ret string) {
+	ret = strings.ToLower(p.Type)
+	if ret == "" {
+		ret = QueryParameterTypeString
+	}
+	return
+}
+
+// GetValue returns the value of the env var (if set and not empty) or the configured value
+func (p *QueryParameter) GetValue() (interface{}, error) {
+	if p.Env != "" {
+		if val, exists := os.LookupEnv(p.Env); exists && val != "" {
+			return val, nil
+		}
+	}
+
+	if p.Value == nil {
+		if p.Env != "" {
+			return nil, fmt.Errorf(`parameter "%v": env var "%v" is not set and no value configured`, p.Name, p.Env)
+		}
+		return nil, fmt.Errorf(`parameter "%v": no value configured`, p.Name)
+	}
+
+	return p.Value, nil
+}
+
+// GetLiteral returns the value of the parameter as escaped kusto literal (eg. "foo", 10, 3600s, datetime(...), dynamic([...]))
+func (p *QueryParameter) GetLiteral() (string, error) {
+	value, err := p.GetValue()
+	if err != nil {
+		return "", err
+	}
+
+	literal, err := formatQueryParameterLiteral(p.GetType(), value)
+	if err != nil {
+		return "", fmt.Errorf(`parameter "%v": %w`, p.Name, err)
+	}
+	return literal, nil
+}
+
+// IsTemplate returns true if the query is rendered as Go template
+//
+//	queries without template actions are prefixed with a "declare query_parameters" statement
+func (c *Query) IsTemplate() bool {
+	return strings.Contains(c.Query, "{{")
+}
+
+// RenderQuery returns the query with all parameters
+//
+//	template: the query is rendered as Go template, parameters are available as escaped literals (eg. {{ .region }})
+//	declare: the parameters are declared with "declare query_parameters(...)" in front of the query
+func (c *Query) RenderQuery() (string, error) {
+	if len(c.Parameters) == 0 {
+		return c.Query, nil
+	}
+
+	literals := make(map[string]string, len(c.Parameters))
+	declarations := make([]string, 0, len(c.Parameters))
+	for i := range c.Parameters {
+		parameter := &c.Parameters[i]
+
+		literal, err := parameter.GetLiteral()
+		if err != nil {
+			return "", err
+		}
+
+		literals[parameter.Name] = literal
+		declarations = append(declarations, fmt.Sprintf("%s:%s = %s", parameter.Name, parameter.GetType(), literal))
+	}
+
+	if !c.IsTemplate() {
+		return fmt.Sprintf("declare query_parameters(%s);\n%s", strings.Join(declarations, ", "), c.Query), nil
+	}
+
+	tmpl, err := c.parseQueryTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	query := strings.Builder{}
+	if err := tmpl.Execute(&query, literals); err != nil {
+		return "", fmt.Errorf("unable to render query template: %w", err)
+	}
+
+	return query.String(), nil
+}
+
+func (c *Query) parseQueryTemplate() (*template.Template, error) {
+	tmpl, err := template.New("query").Option("missingkey=error").Parse(c.Query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse query template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// formatQueryParameterLiteral converts the value into a kusto literal of the type
+func formatQueryParameterLiteral(parameterType string, value interface{}) (string, error) {
+	switch parameterType {
+	case QueryParameterTypeString:
+		switch v := value.(type) {
+		case string:
+			return quoteKustoString(v)
+		case float64, bool:
+			return quoteKustoString(fmt.Sprintf("%v", v))
+		}
+
+	case QueryParameterTypeLong:
+		switch v := value.(type) {
+		case float64:
+			if v == math.Trunc(v) && !math.IsInf(v, 0) {
+				return strconv.FormatInt(int64(v), 10), nil
+			}
+		case string:
+			if val, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return strconv.FormatInt(val, 10), nil
+			}
+		}
+
+	case QueryParameterTypeReal:
+		val, ok := value.(float64)
+		if str, isString := value.(string); isString {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+			val, ok = parsed, err == nil
+		}
+		if ok && !math.IsNaN(val) && !math.IsInf(val, 0) {
+			return strconv.FormatFloat(val, 'g', -1, 64), nil
+		}
+
+	case QueryParameterTypeBool:
+		switch v := value.(type) {
+		case bool:
+			return strconv.FormatBool(v), nil
+		case string:
+			if val, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return strconv.FormatBool(val), nil
+			}
+		}
+
+	case QueryParameterTypeTimespan:
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64) + "s", nil
+		case string:
+			if seconds := convertDurationToSeconds(v); seconds != "" {
+				return seconds + "s", nil
+			}
+		}
+
+	case QueryParameterTypeDatetime:
+		if v, ok := value.(string); ok {
+			if val := convertStringToTime(strings.TrimSpace(v)); val != nil {
+				return fmt.Sprintf("datetime(%s)", val.UTC().Format(time.RFC3339Nano)), nil
+			}
+		}
+
+	case QueryParameterTypeDynamic:
+		if v, ok := value.(string); ok {
+			if !json.Valid([]byte(v)) {
+				return "", fmt.Errorf("invalid json for type %v: %v", parameterType, v)
+			}
+			value = nil
+			if err := json.Unmarshal([]byte(v), &value); err != nil {
+				return "", err
+			}
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("dynamic(%s)", data), nil
+
+	default:
+		return "", fmt.Errorf(`unsupported type "%v"`, parameterType)
+	}
+
+	return "", fmt.Errorf("invalid value for type %v: %v", parameterType, value)
+}
+
+// quoteKustoString returns the value as double quoted kusto string literal
+func quoteKustoString(value string) (string, error) {
+	ret := strings.Builder{}
+	ret.WriteByte('"')
+	for _, char := range value {
+		switch char {
+		case '\\':
+			ret.WriteString(`\\`)
+		case '"':
+			ret.WriteString(`\"`)
+		case '\n':
+			ret.WriteString(`\n`)
+		case '\r':
+			ret.WriteString(`\r`)
+		case '\t':
+			ret.WriteString(`\t`)
+		default:
+			if char < 0x20 || char == 0x7f {
+				return "", errors.New("control characters are not allowed in string values")
+			}
+			ret.WriteRune(char)
+		}
+	}
+	ret.WriteByte('"')
+	return ret.String(), nil
+}
@@ -0,0 +1,172 @@
+package kusto
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func newTestQuery(t *testing.T, data string) Query {
+	t.Helper()
+
+	query := Query{}
+	if err := yaml.Unmarshal([]byte(data), &query); err != nil {
+		t.Fatal(err)
+	}
+	return query
+}
+
+func Test_RenderQueryTemplate(t *testing.T) {
+	t.Setenv("KUSTO_TEST_REGION", `west"europe`)
+
+	query := newTestQuery(t, `
+metric: azure_test
+query: |-
+  Heartbeat
+  | where TimeGenerated > ago({{ .timespan }}) and Region == {{ .region }}
+  | where SubscriptionId in ({{ .subscriptions }}) and Count > {{ .threshold }} and Enabled == {{ .enabled }}
+parameters:
+  - name: timespan
+    type: timespan
+    value: 1h
+  - name: region
+    env: KUSTO_TEST_REGION
+    value: westeurope
+  - name: subscriptions
+    type: dynamic
+    value: [foo, bar]
+  - name: threshold
+    type: long
+    value: 10
+  - name: enabled
+    type: bool
+    env: KUSTO_TEST_UNSET
+    value: true
+`)
+
+	if err := query.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := query.RenderQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Heartbeat\n" +
+		`| where TimeGenerated > ago(3600s) and Region == "west\"europe"` + "\n" +
+		`| where SubscriptionId in (dynamic(["foo","bar"])) and Count > 10 and Enabled == true`
+	if rendered != expected {
+		t.Errorf("unexpected query:\n%v\nexpected:\n%v", rendered, expected)
+	}
+}
+
+func Test_RenderQueryDeclare(t *testing.T) {
+	query := newTestQuery(t, `
+metric: azure_test
+query: Heartbeat | where TimeGenerated > since and Computer == computer
+parameters:
+  - name: since
+    type: datetime
+    value: "2023-01-02T03:04:05Z"
+  - name: computer
+    value: "vm\\1"
+`)
+
+	rendered, err := query.RenderQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `declare query_parameters(since:datetime = datetime(2023-01-02T03:04:05Z), computer:string = "vm\\1");` + "\n" +
+		`Heartbeat | where TimeGenerated > since and Computer == computer`
+	if rendered != expected {
+		t.Errorf("unexpected query:\n%v\nexpected:\n%v", rendered, expected)
+	}
+
+	// queries without parameters are not changed
+	query.Parameters = nil
+	if rendered, _ := query.RenderQuery(); rendered != query.Query {
+		t.Errorf("unexpected query: %v", rendered)
+	}
+}
+
+func Test_QueryParameterLiterals(t *testing.T) {
+	tests := []struct {
+		parameterType string
+		value         interface{}
+		expected      string
+	}{
+		{QueryParameterTypeString, `foo" | take 1 //`, `"foo\" | take 1 //"`},
+		{QueryParameterTypeString, "a\nb\\", `"a\nb\\"`},
+		{QueryParameterTypeLong, "42", "42"},
+		{QueryParameterTypeLong, "42 | take 1", ""},
+		{QueryParameterTypeReal, float64(1.5), "1.5"},
+		{QueryParameterTypeReal, "NaN", ""},
+		{QueryParameterTypeBool, "yes", ""},
+		{QueryParameterTypeTimespan, "1.02:00:00", "93600s"},
+		{QueryParameterTypeTimespan, "PT15M", "900s"},
+		{QueryParameterTypeTimespan, "1h) | take 1", ""},
+		{QueryParameterTypeDatetime, "yesterday", ""},
+		{QueryParameterTypeDynamic, `{"a":"b)"}`, `dynamic({"a":"b)"})`},
+		{QueryParameterTypeDynamic, `[1, 2`, ""},
+		{QueryParameterTypeString, "foo\x00", ""},
+	}
+
+	for _, test := range tests {
+		literal, err := formatQueryParameterLiteral(test.parameterType, test.value)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%v %q: expected error, got %v", test.parameterType, test.value, literal)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%v %q: %v", test.parameterType, test.value, err)
+		} else if literal != test.expected {
+			t.Errorf("%v %q: expected %v, got %v", test.parameterType, test.value, test.expected, literal)
+		}
+	}
+}
+
+func Test_QueryParameterValidation(t *testing.T) {
+	_, err := parseConfig("queries.yaml", []byte(`
+queries:
+  - metric: azure_test
+    query: "Heartbeat | where Region == {{ .regoin }}"
+    defaultField:
+      type: ignore
+    parameters:
+      - name: region
+        value: westeurope
+      - name: region
+        value: northeurope
+      - name: "1st"
+        type: long
+        value: foo
+      - name: threshold
+        type: int
+        value: 1
+      - name: timespan
+        type: timespan
+`))
+
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	for _, expected := range []string{
+		`queries.yaml:10: query "azure_test": queries[0].parameters[1]: duplicate parameter "region"`,
+		`queries.yaml:12: query "azure_test": queries[0].parameters[2]: invalid parameter name "1st"`,
+		`queries.yaml:14: query "azure_test": queries[0].parameters[2].value: parameter "1st": invalid value for type long: foo`,
+		`queries.yaml:16: query "azure_test": queries[0].parameters[3].type: parameter "threshold": unsupported type "int"`,
+		`queries.yaml:18: query "azure_test": queries[0].parameters[4]: parameter "timespan": no value or env var set`,
+		`queries.yaml:4: query "azure_test": queries[0].query: unable to render query template: `,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error %q, got:\n%v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package kusto
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// GetMetricLabelNames returns the label names of all metrics which can be built by BuildPrometheusMetricList
+//
+//	fails if the label names depend on the query result (defaultField or expand without own configuration
+//	which would add every unconfigured column as label)
+func (m *QueryMetric) GetMetricLabelNames(name string) (map[string][]string, error) {
+	schema := map[string]map[string]bool{}
+	if err := m.collectMetricLabelNames(name, []string{}, schema); err != nil {
+		return nil, err
+	}
+
+	ret := map[string][]string{}
+	for metricName, labelNames := range schema {
+		ret[metricName] = slices.Sorted(maps.Keys(labelNames))
+	}
+	return ret, nil
+}
+
+func (m *QueryMetric) collectMetricLabelNames(name string, idLabels []string, schema map[string]map[string]bool) error {
+	addLabels := func(metricName string, labelNames ...string) {
+		if _, exists := schema[metricName]; !exists {
+			schema[metricName] = map[string]bool{}
+		}
+		for _, labelName := range labelNames {
+			schema[metricName][labelName] = true
+		}
+	}
+
+	if isDynamicLabelField(m.DefaultField) {
+		return fmt.Errorf(`metric "%v": labels depend on query result, defaultField has to be of type "%v", "%v" or "%v"`, name, MetricFieldTypeIgnore, MetricFieldTypeValue, MetricFieldTypeTime)
+	}
+
+	// id labels are added to all metrics of the row
+	idLabels = slices.Clone(idLabels)
+	for _, field := range m.Fields {
+		if field.IsTypeId() && !field.IsExpand() && field.Metric == "" {
+			idLabels = append(idLabels, field.GetTargetFieldName(field.GetSourceField()))
+		}
+	}
+
+	mainLabels := slices.Collect(maps.Keys(m.Labels))
+	for _, field := range m.Fields {
+		if field.IsTypeIgnore() {
+			continue
+		}
+
+		if field.IsExpand() {
+			subMetricName := field.Metric
+			if subMetricName == "" {
+				subMetricName = fmt.Sprintf("%s_%s", name, field.Name)
+			}
+
+			subMetricConfig := QueryMetric{}
+			if field.Expand != nil {
+				subMetricConfig = *field.Expand
+			}
+
+			if err := subMetricConfig.collectMetricLabelNames(subMetricName, idLabels, schema); err != nil {
+				return err
+			}
+			continue
+		}
+
+		labelNames := slices.Collect(maps.Keys(field.Labels))
+		if !field.IsTypeValue() && !field.IsTypeTimestamp() {
+			labelNames = append(labelNames, field.GetTargetFieldName(field.GetSourceField()))
+		}
+
+		if field.Metric == "" {
+			mainLabels = append(mainLabels, labelNames...)
+		} else {
+			addLabels(field.Metric, append(labelNames, idLabels...)...)
+		}
+	}
+
+	if m.IsPublished() {
+		addLabels(name, append(mainLabels, idLabels...)...)
+	}
+
+	return nil
+}
+
+// isDynamicLabelField returns true if the field adds every unconfigured column as label
+func isDynamicLabelField(field MetricField) bool {
+	switch field.GetType() {
+	case MetricFieldTypeIgnore, MetricFieldTypeValue, MetricFieldTypeTime:
+		return false
+	}
+	return true
+}
+
+// GetMetricMetadata returns the metadata of all metrics which can be built by BuildPrometheusMetricList
+//
+//	metadata of the query (or expand) and of the field are merged, fails if the metric types are conflicting
+func (m *QueryMetric) GetMetricMetadata(name string) (map[string]MetricMetadata, error) {
+	ret := map[string]MetricMetadata{}
+	if err := m.collectMetricMetadata(name, m.MetricMetadata, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (m *QueryMetric) collectMetricMetadata(name string, metadata MetricMetadata, ret map[string]MetricMetadata) error {
+	addMetadata := func(metricName string, metadata MetricMetadata) error {
+		merged, err := mergeMetricMetadata(metricName, ret[metricName], metadata)
+		if err != nil {
+			return err
+		}
+		ret[metricName] = merged
+		return nil
+	}
+
+	for _, field := range m.Fields {
+		if field.IsTypeIgnore() {
+			continue
+		}
+
+		if field.IsExpand() {
+			subMetricName := field.Metric
+			if subMetricName == "" {
+				subMetricName = fmt.Sprintf("%s_%s", name, field.Name)
+			}
+
+			subMetricConfig := QueryMetric{}
+			if field.Expand != nil {
+				subMetricConfig = *field.Expand
+			}
+
+			subMetadata, err := mergeMetricMetadata(subMetricName, field.MetricMetadata, subMetricConfig.MetricMetadata)
+			if err != nil {
+				return err
+			}
+
+			if err := subMetricConfig.collectMetricMetadata(subMetricName, subMetadata, ret); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if field.Metric != "" {
+			if err := addMetadata(field.Metric, field.MetricMetadata); err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.IsPublished() {
+		if err := addMetadata(name, metadata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeMetricMetadata merges the metadata of a metric which is defined multiple times
+//
+//	help is taken from the first definition, types, units and buckets have to be the same if set
+func mergeMetricMetadata(name string, a, b MetricMetadata) (MetricMetadata, error) {
+	ret := a
+
+	if b.MetricType != "" {
+		if ret.MetricType != "" && !strings.EqualFold(ret.MetricType, b.MetricType) {
+			return ret, fmt.Errorf(`metric "%v": conflicting metric types "%v" and "%v"`, name, ret.MetricType, b.MetricType)
+		}
+		ret.MetricType = b.MetricType
+	}
+
+	if b.Unit != "" {
+		if ret.Unit != "" && ret.Unit != b.Unit {
+			return ret, fmt.Errorf(`metric "%v": conflicting units "%v" and "%v"`, name, ret.Unit, b.Unit)
+		}
+		ret.Unit = b.Unit
+	}
+
+	if len(b.Buckets) > 0 {
+		if len(ret.Buckets) > 0 && !slices.Equal(ret.Buckets, b.Buckets) {
+			return ret, fmt.Errorf(`metric "%v": conflicting buckets %v and %v`, name, ret.Buckets, b.Buckets)
+		}
+		ret.Buckets = b.Buckets
+	}
+
+	if ret.Help == "" {
+		ret.Help = b.Help
+	}
+
+	return ret, nil
+}
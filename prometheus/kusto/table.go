@@ -0,0 +1,232 @@
+package kusto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ColumnTypeBool     = "bool"
+	ColumnTypeBoolean  = "boolean"
+	ColumnTypeDatetime = "datetime"
+	ColumnTypeDate     = "date"
+	ColumnTypeDynamic  = "dynamic"
+	ColumnTypeGuid     = "guid"
+	ColumnTypeUuid     = "uuid"
+	ColumnTypeInt      = "int"
+	ColumnTypeLong     = "long"
+	ColumnTypeReal     = "real"
+	ColumnTypeDouble   = "double"
+	ColumnTypeDecimal  = "decimal"
+	ColumnTypeString   = "string"
+	ColumnTypeTimespan = "timespan"
+	ColumnTypeTime     = "time"
+)
+
+type (
+	// QueryResponse is the query response of Log Analytics (and Azure Data Explorer v1) with typed tables
+	QueryResponse struct {
+		Tables []QueryResponseTable `json:"tables"`
+	}
+
+	QueryResponseTable struct {
+		Name    string                `json:"name"`
+		Columns []QueryResponseColumn `json:"columns"`
+		Rows    [][]json.RawMessage   `json:"rows"`
+	}
+
+	QueryResponseColumn struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+)
+
+// ParseQueryResponse parses the tables of the query response and returns the rows of all tables
+//
+//	see QueryResponseTable.GetRows for the type conversion
+func ParseQueryResponse(data []byte) ([]map[string]interface{}, error) {
+	response := QueryResponse{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	return response.GetRows()
+}
+
+// GetRows returns the rows of all tables
+func (r *QueryResponse) GetRows() ([]map[string]interface{}, error) {
+	rows := []map[string]interface{}{}
+	for i := range r.Tables {
+		tableRows, err := r.Tables[i].GetRows()
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, tableRows...)
+	}
+	return rows, nil
+}
+
+// GetRows converts the table rows into maps with the column names as keys, values are converted by the column type:
+//
+//	bool: bool, int/long: int64, real/decimal: float64, datetime: time.Time, timespan: float64 (seconds),
+//	dynamic: map[string]interface{} or []interface{} (usable for expand), string/guid: string, null: nil
+func (t *QueryResponseTable) GetRows() ([]map[string]interface{}, error) {
+	rows := make([]map[string]interface{}, 0, len(t.Rows))
+	for rowNum, tableRow := range t.Rows {
+		if len(tableRow) != len(t.Columns) {
+			return nil, fmt.Errorf(`table "%v": row %v: expected %v columns, got %v`, t.Name, rowNum, len(t.Columns), len(tableRow))
+		}
+
+		row := make(map[string]interface{}, len(t.Columns))
+		for colNum, column := range t.Columns {
+			value, err := ConvertColumnValue(column.Type, tableRow[colNum])
+			if err != nil {
+				return nil, fmt.Errorf(`table "%v": row %v: column "%v" (%v): %w`, t.Name, rowNum, column.Name, column.Type, err)
+			}
+			row[column.Name] = value
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// ConvertColumnValue converts the json value by the column type (see QueryResponseTable.GetRows)
+func ConvertColumnValue(columnType string, value json.RawMessage) (interface{}, error) {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return nil, nil
+	}
+
+	switch strings.ToLower(columnType) {
+	case ColumnTypeString, ColumnTypeGuid, ColumnTypeUuid:
+		var ret interface{}
+		if err := json.Unmarshal(value, &ret); err != nil {
+			return nil, err
+		}
+		if val, ok := ret.(string); ok {
+			return val, nil
+		}
+		return string(value), nil
+
+	case ColumnTypeBool, ColumnTypeBoolean:
+		var ret interface{}
+		if err := json.Unmarshal(value, &ret); err != nil {
+			return nil, err
+		}
+		switch v := ret.(type) {
+		case bool:
+			return v, nil
+		case float64:
+			return v != 0, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+		return nil, fmt.Errorf("invalid bool value %s", value)
+
+	case ColumnTypeInt, ColumnTypeLong:
+		number, err := unmarshalColumnNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		if ret, err := strconv.ParseInt(number, 10, 64); err == nil {
+			return ret, nil
+		}
+		// fall back to float for exponent notation
+		ret, err := strconv.ParseFloat(number, 64)
+		if err != nil || ret != math.Trunc(ret) {
+			return nil, fmt.Errorf("invalid %v value %s", columnType, value)
+		}
+		return int64(ret), nil
+
+	case ColumnTypeReal, ColumnTypeDouble, ColumnTypeDecimal:
+		number, err := unmarshalColumnNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		// real values can be returned as "NaN", "Infinity" and "-Infinity"
+		switch number {
+		case "Infinity":
+			number = "+Inf"
+		case "-Infinity":
+			number = "-Inf"
+		}
+		return strconv.ParseFloat(number, 64)
+
+	case ColumnTypeDatetime, ColumnTypeDate:
+		var ret string
+		if err := json.Unmarshal(value, &ret); err != nil {
+			return nil, err
+		}
+		if ret == "" {
+			return nil, nil
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, ret)
+		if err != nil {
+			return nil, err
+		}
+		return parsed, nil
+
+	case ColumnTypeTimespan, ColumnTypeTime:
+		var ret interface{}
+		if err := json.Unmarshal(value, &ret); err != nil {
+			return nil, err
+		}
+		switch v := ret.(type) {
+		case float64:
+			// ticks (100ns)
+			return v / 1e7, nil
+		case string:
+			if v == "" {
+				return nil, nil
+			}
+			if seconds := convertDurationToSeconds(v); seconds != "" {
+				return strconv.ParseFloat(seconds, 64)
+			}
+		}
+		return nil, fmt.Errorf("invalid timespan value %s", value)
+
+	case ColumnTypeDynamic:
+		var ret interface{}
+		if err := json.Unmarshal(value, &ret); err != nil {
+			return nil, err
+		}
+		// dynamic values are usually returned as json encoded string
+		if v, ok := ret.(string); ok {
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(v), &decoded); err == nil {
+				return decoded, nil
+			}
+		}
+		return ret, nil
+
+	default:
+		var ret interface{}
+		if err := json.Unmarshal(value, &ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+}
+
+// unmarshalColumnNumber returns the number as string (numbers can also be returned as json string)
+func unmarshalColumnNumber(value json.RawMessage) (string, error) {
+	if value[0] == '"' {
+		var ret string
+		if err := json.Unmarshal(value, &ret); err != nil {
+			return "", err
+		}
+		return ret, nil
+	}
+
+	var ret json.Number
+	if err := json.Unmarshal(value, &ret); err != nil {
+		return "", err
+	}
+	return ret.String(), nil
+}
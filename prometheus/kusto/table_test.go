@@ -0,0 +1,146 @@
+package kusto
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const testQueryResponse = `{
+  "tables": [
+    {
+      "name": "PrimaryResult",
+      "columns": [
+        {"name": "TimeGenerated", "type": "datetime"},
+        {"name": "Computer", "type": "string"},
+        {"name": "_ResourceId", "type": "guid"},
+        {"name": "Count", "type": "long"},
+        {"name": "Avg", "type": "real"},
+        {"name": "Healthy", "type": "bool"},
+        {"name": "Duration", "type": "timespan"},
+        {"name": "Disks", "type": "dynamic"},
+        {"name": "Empty", "type": "string"}
+      ],
+      "rows": [
+        [
+          "2023-01-02T03:04:05.5Z", "vm-1", "e8b5a4a0-0000-0000-0000-000000000001", 9007199254740993, 12.5, true,
+          "1.02:00:00.5", "[{\"name\":\"os\",\"sizeGb\":128},{\"name\":\"data\",\"sizeGb\":512}]", null
+        ],
+        [
+          "2023-01-02T03:04:06Z", "vm-2", "e8b5a4a0-0000-0000-0000-000000000002", 3, "NaN", false,
+          "00:00:30", "{\"name\":\"os\",\"sizeGb\":64}", ""
+        ]
+      ]
+    }
+  ]
+}`
+
+func Test_ParseQueryResponse(t *testing.T) {
+	rows, err := ParseQueryResponse([]byte(testQueryResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", len(rows))
+	}
+
+	row := rows[0]
+	if v, ok := row["TimeGenerated"].(time.Time); !ok || !v.Equal(time.Date(2023, 1, 2, 3, 4, 5, 5e8, time.UTC)) {
+		t.Errorf("unexpected datetime: %#v", row["TimeGenerated"])
+	}
+	if v, ok := row["_ResourceId"].(string); !ok || v != "e8b5a4a0-0000-0000-0000-000000000001" {
+		t.Errorf("unexpected guid: %#v", row["_ResourceId"])
+	}
+	if v, ok := row["Count"].(int64); !ok || v != 9007199254740993 {
+		t.Errorf("unexpected long: %#v", row["Count"])
+	}
+	if v, ok := row["Avg"].(float64); !ok || v != 12.5 {
+		t.Errorf("unexpected real: %#v", row["Avg"])
+	}
+	if v, ok := row["Healthy"].(bool); !ok || !v {
+		t.Errorf("unexpected bool: %#v", row["Healthy"])
+	}
+	if v, ok := row["Duration"].(float64); !ok || v != 93600.5 {
+		t.Errorf("unexpected timespan: %#v", row["Duration"])
+	}
+	if v, ok := row["Disks"].([]interface{}); !ok || len(v) != 2 {
+		t.Errorf("unexpected dynamic: %#v", row["Disks"])
+	}
+	if v, exists := row["Empty"]; !exists || v != nil {
+		t.Errorf("unexpected null: %#v", row["Empty"])
+	}
+
+	row = rows[1]
+	if v, ok := row["Avg"].(float64); !ok || !math.IsNaN(v) {
+		t.Errorf("unexpected real: %#v", row["Avg"])
+	}
+	if v, ok := row["Duration"].(float64); !ok || v != 30 {
+		t.Errorf("unexpected timespan: %#v", row["Duration"])
+	}
+	if v, ok := row["Disks"].(map[string]interface{}); !ok || v["name"] != "os" {
+		t.Errorf("unexpected dynamic: %#v", row["Disks"])
+	}
+}
+
+func Test_ParseQueryResponseErrors(t *testing.T) {
+	tests := []struct {
+		response string
+		expected string
+	}{
+		{`{"tables":[{"name":"t","columns":[{"name":"a","type":"long"}],"rows":[["foo"]]}]}`, `table "t": row 0: column "a" (long): `},
+		{`{"tables":[{"name":"t","columns":[{"name":"a","type":"datetime"}],"rows":[["yesterday"]]}]}`, `table "t": row 0: column "a" (datetime): `},
+		{`{"tables":[{"name":"t","columns":[{"name":"a","type":"timespan"}],"rows":[["forever"]]}]}`, `table "t": row 0: column "a" (timespan): invalid timespan value "forever"`},
+		{`{"tables":[{"name":"t","columns":[{"name":"a","type":"string"}],"rows":[["foo","bar"]]}]}`, `table "t": row 0: expected 1 columns, got 2`},
+	}
+
+	for _, test := range tests {
+		_, err := ParseQueryResponse([]byte(test.response))
+		if err == nil || !strings.HasPrefix(err.Error(), test.expected) {
+			t.Errorf("expected error %q, got %v", test.expected, err)
+		}
+	}
+}
+
+func Test_ParseQueryResponseMetrics(t *testing.T) {
+	rows, err := ParseQueryResponse([]byte(testQueryResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queryConfig := QueryMetric{}
+	if err := yaml.Unmarshal([]byte(`
+fields:
+- name: Computer
+  type: id
+- name: TimeGenerated
+  type: timestamp
+- name: Duration
+  type: value
+- name: Disks
+  type: expand
+  metric: disk_size
+  expand:
+    fields:
+    - name: name
+    - name: sizeGb
+      type: value
+defaultField:
+  type: ignore
+`), &queryConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := BuildPrometheusMetricList("duration", queryConfig, rows[0])
+
+	if list := metrics["duration"]; len(list) != 1 || *list[0].Value != 93600.5 || list[0].Labels["Computer"] != "vm-1" || list[0].Timestamp == nil {
+		t.Errorf("unexpected duration metrics: %v", list)
+	}
+
+	if list := metrics["disk_size"]; len(list) != 2 || *list[1].Value != 512 || list[1].Labels["name"] != "data" || list[1].Labels["Computer"] != "vm-1" {
+		t.Errorf("unexpected disk metrics: %v", list)
+	}
+}
@@ -0,0 +1,304 @@
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	LintLabelStyleAny       = ""
+	LintLabelStyleSnakeCase = "snake_case"
+	LintLabelStyleCamelCase = "camelCase"
+
+	lintKindCounter   = "counter"
+	lintKindGauge     = "gauge"
+	lintKindUntyped   = "untyped"
+	lintKindHistogram = "histogram"
+	lintKindSummary   = "summary"
+
+	// max number of variable labels which are probed while resolving a desc (see lintDescMetric)
+	lintMaxLabels = 128
+)
+
+var (
+	lintLabelSnakeCaseRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	lintLabelCamelCaseRegexp = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+	// non base units and the base unit which should be used instead
+	lintNonBaseUnits = map[string]string{
+		"nanoseconds":  "seconds",
+		"microseconds": "seconds",
+		"milliseconds": "seconds",
+		"ms":           "seconds",
+		"minutes":      "seconds",
+		"hours":        "seconds",
+		"days":         "seconds",
+		"bits":         "bytes",
+		"kilobytes":    "bytes",
+		"megabytes":    "bytes",
+		"gigabytes":    "bytes",
+		"kibibytes":    "bytes",
+		"mebibytes":    "bytes",
+		"gibibytes":    "bytes",
+		"percent":      "ratio",
+	}
+)
+
+type (
+	// MetricLinter checks metric names and labels against the Prometheus naming conventions
+	MetricLinter struct {
+		// style of label names (LintLabelStyleSnakeCase, LintLabelStyleCamelCase or LintLabelStyleAny)
+		LabelStyle string
+
+		// labels which every metric has to provide (eg. subscriptionID)
+		RequiredLabels []string
+
+		// prefixes of dynamic labels (eg. tag_), only the prefix is checked and not the label style
+		LabelPrefixes []string
+	}
+
+	// LintProblem is a violation found by MetricLinter
+	LintProblem struct {
+		Metric string
+		Text   string
+	}
+
+	lintMetric struct {
+		name       string
+		help       string
+		kind       string
+		labelNames []string
+	}
+
+	lintDescCollector struct {
+		desc   *prometheus.Desc
+		metric prometheus.Metric
+	}
+)
+
+// NewMetricLinter creates a linter with camelCase labels (as used by the Azure exporters) and the dynamic label prefix
+//
+//	Azure exporters should use armclient.AzurePrometheusLabelPrefix, an empty prefix disables the prefix check
+func NewMetricLinter(labelPrefix string) *MetricLinter {
+	linter := &MetricLinter{
+		LabelStyle:    LintLabelStyleCamelCase,
+		LabelPrefixes: []string{},
+	}
+
+	if labelPrefix != "" {
+		linter.LabelPrefixes = append(linter.LabelPrefixes, labelPrefix)
+	}
+
+	return linter
+}
+
+func (p LintProblem) String() string {
+	if p.Metric == "" {
+		return p.Text
+	}
+	return fmt.Sprintf(`metric "%v": %v`, p.Metric, p.Text)
+}
+
+// Lint checks the metric description (see MetricList.SetDesc)
+func (l *MetricLinter) Lint(desc MetricDesc) []LintProblem {
+	metric := lintMetric{
+		name:       desc.Name,
+		help:       desc.Help,
+		kind:       lintValueTypeKind(desc.ValueType),
+		labelNames: append([]string{}, desc.LabelNames...),
+	}
+	for labelName := range desc.ConstLabels {
+		metric.labelNames = append(metric.labelNames, labelName)
+	}
+
+	return l.lint(metric)
+}
+
+// LintCollector checks all metrics described by the collector (eg. prometheus vecs or MetricList)
+func (l *MetricLinter) LintCollector(collector prometheus.Collector) (problems []LintProblem) {
+	kind := lintKindUntyped
+	switch collector := collector.(type) {
+	case *prometheus.CounterVec:
+		kind = lintKindCounter
+	case *prometheus.GaugeVec:
+		kind = lintKindGauge
+	case *prometheus.HistogramVec:
+		kind = lintKindHistogram
+	case *prometheus.SummaryVec:
+		kind = lintKindSummary
+	case *MetricList:
+		if collector.collector != nil {
+			return l.Lint(collector.collector.metricDesc)
+		}
+	}
+
+	descChannel := make(chan *prometheus.Desc)
+	go func() {
+		collector.Describe(descChannel)
+		close(descChannel)
+	}()
+
+	for desc := range descChannel {
+		metric, err := lintDescMetric(desc)
+		if err != nil {
+			problems = append(problems, LintProblem{Text: fmt.Sprintf(`invalid metric description: %v`, err)})
+			continue
+		}
+		metric.kind = kind
+		problems = append(problems, l.lint(metric)...)
+	}
+
+	return
+}
+
+func (l *MetricLinter) lint(metric lintMetric) (problems []LintProblem) {
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, LintProblem{Metric: metric.name, Text: fmt.Sprintf(format, args...)})
+	}
+
+	// metric name
+	if !IsValidMetricName(metric.name) {
+		addProblem(`invalid metric name`)
+	} else if strings.ToLower(metric.name) != metric.name {
+		addProblem(`metric name should be snake_case`)
+	}
+
+	if metric.help == "" {
+		addProblem(`no help text`)
+	}
+
+	switch metric.kind {
+	case lintKindCounter:
+		if !strings.HasSuffix(metric.name, "_total") {
+			addProblem(`counter metrics should have "_total" suffix`)
+		}
+	default:
+		if strings.HasSuffix(metric.name, "_total") {
+			addProblem(`non-counter metrics should not have "_total" suffix`)
+		}
+	}
+
+	if metric.kind != lintKindHistogram && metric.kind != lintKindSummary {
+		for _, suffix := range []string{"_count", "_sum", "_bucket"} {
+			if strings.HasSuffix(metric.name, suffix) {
+				addProblem(`non-histogram and non-summary metrics should not have "%v" suffix`, suffix)
+			}
+		}
+	}
+
+	for _, part := range strings.Split(metric.name, "_") {
+		if baseUnit, exists := lintNonBaseUnits[part]; exists {
+			addProblem(`use base unit "%v" instead of "%v"`, baseUnit, part)
+		}
+	}
+
+	// labels
+	slices.Sort(metric.labelNames)
+	for _, labelName := range metric.labelNames {
+		if !IsValidLabelName(labelName) {
+			addProblem(`invalid label name "%v"`, labelName)
+			continue
+		}
+
+		if prefix, isDynamic := l.labelPrefix(labelName); isDynamic {
+			if !strings.HasPrefix(labelName, prefix) {
+				addProblem(`label "%v" should use prefix "%v"`, labelName, prefix)
+			}
+			continue
+		}
+
+		switch l.LabelStyle {
+		case LintLabelStyleSnakeCase:
+			if !lintLabelSnakeCaseRegexp.MatchString(labelName) {
+				addProblem(`label "%v" should be snake_case`, labelName)
+			}
+		case LintLabelStyleCamelCase:
+			if !lintLabelCamelCaseRegexp.MatchString(labelName) {
+				addProblem(`label "%v" should be camelCase`, labelName)
+			}
+		}
+	}
+
+	for _, labelName := range l.RequiredLabels {
+		if !slices.Contains(metric.labelNames, labelName) {
+			addProblem(`required label "%v" is missing`, labelName)
+		}
+	}
+
+	return
+}
+
+// labelPrefix returns the dynamic label prefix if the label starts with it (case insensitive)
+func (l *MetricLinter) labelPrefix(labelName string) (string, bool) {
+	for _, prefix := range l.LabelPrefixes {
+		if len(labelName) > len(prefix) && strings.EqualFold(labelName[:len(prefix)], prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+func lintValueTypeKind(valueType prometheus.ValueType) string {
+	switch valueType {
+	case prometheus.CounterValue:
+		return lintKindCounter
+	case prometheus.UntypedValue:
+		return lintKindUntyped
+	}
+	return lintKindGauge
+}
+
+// lintDescMetric resolves name, help and label names of the desc
+//
+//	the fields of prometheus.Desc are not exported, so a const metric with placeholder label values
+//	is created for the desc and gathered by a temporary registry
+func lintDescMetric(desc *prometheus.Desc) (metric lintMetric, err error) {
+	var constMetric prometheus.Metric
+	for labelCount := 0; labelCount <= lintMaxLabels; labelCount++ {
+		labelValues := make([]string, labelCount)
+		for i := range labelValues {
+			labelValues[i] = "lint"
+		}
+
+		if constMetric, err = prometheus.NewConstMetric(desc, prometheus.UntypedValue, 0, labelValues...); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	if err = registry.Register(&lintDescCollector{desc: desc, metric: constMetric}); err != nil {
+		return
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		return
+	}
+
+	for _, family := range families {
+		metric.name = family.GetName()
+		metric.help = family.GetHelp()
+		for _, sample := range family.GetMetric() {
+			for _, label := range sample.GetLabel() {
+				metric.labelNames = append(metric.labelNames, label.GetName())
+			}
+		}
+	}
+
+	return
+}
+
+func (c *lintDescCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *lintDescCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- c.metric
+}
@@ -0,0 +1,90 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func expectLintProblems(t *testing.T, problems []LintProblem, expected ...string) {
+	t.Helper()
+
+	if len(problems) != len(expected) {
+		t.Errorf("expected %v lint problems, got %v", len(expected), problems)
+		return
+	}
+
+	for i, problem := range problems {
+		if !strings.Contains(problem.String(), expected[i]) {
+			t.Errorf("expected lint problem %q, got %q", expected[i], problem.String())
+		}
+	}
+}
+
+func Test_MetricLinter(t *testing.T) {
+	linter := NewMetricLinter("tag_")
+	linter.RequiredLabels = []string{"subscriptionID"}
+
+	expectLintProblems(t, linter.LintCollector(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "azurerm_resource_info", Help: "Azure resource"},
+		[]string{"subscriptionID", "resourceID", "tag_owner", "tag_CostCenter"},
+	)))
+
+	expectLintProblems(t, linter.LintCollector(prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "azurerm_request_duration_milliseconds"},
+		[]string{"subscription_id", "Tag_owner"},
+	)),
+		`no help text`,
+		`should have "_total" suffix`,
+		`use base unit "seconds" instead of "milliseconds"`,
+		`label "Tag_owner" should use prefix "tag_"`,
+		`label "subscription_id" should be camelCase`,
+		`required label "subscriptionID" is missing`,
+	)
+
+	expectLintProblems(t, linter.LintCollector(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "azurerm_requestCount_total", Help: "Requests", ConstLabels: prometheus.Labels{"subscriptionID": "foo"}},
+		[]string{},
+	)),
+		`should be snake_case`,
+		`non-counter metrics should not have "_total" suffix`,
+	)
+
+	expectLintProblems(t, linter.LintCollector(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "1nvalid", Help: "Invalid"},
+		[]string{"subscriptionID"},
+	)),
+		`invalid metric name`,
+	)
+
+	expectLintProblems(t, linter.LintCollector(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "azurerm_resource_info", Help: "Azure resource"},
+		[]string{"subscriptionID", "subscriptionID"},
+	)),
+		`invalid metric description`,
+	)
+
+	linter.LabelStyle = LintLabelStyleSnakeCase
+	linter.RequiredLabels = nil
+	expectLintProblems(t, linter.Lint(MetricDesc{
+		Name:       "azurerm_resource_count",
+		Help:       "Resource count",
+		LabelNames: []string{"resourceID", "resource_group"},
+	}),
+		`should not have "_count" suffix`,
+		`label "resourceID" should be snake_case`,
+	)
+}
+
+func Test_MetricLinterMetricList(t *testing.T) {
+	m := NewMetricsList()
+	m.SetDesc(MetricDesc{
+		Name:       "azurerm_costs",
+		Help:       "Costs",
+		LabelNames: []string{"subscriptionID"},
+		ValueType:  prometheus.CounterValue,
+	})
+
+	expectLintProblems(t, NewMetricLinter("tag_").LintCollector(m), `should have "_total" suffix`)
+}
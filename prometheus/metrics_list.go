@@ -1,6 +1,8 @@
 package prometheus
 
 import (
+	"bytes"
+	"encoding/json"
 	"sync"
 	"time"
 
@@ -13,12 +15,34 @@ import (
 type MetricRow struct {
 	Labels prometheus.Labels `json:"labels"`
 	Value  float64           `json:"value"`
+
+	// optional sample timestamp, only exported by MetricList used as prometheus.Collector (see SetDesc)
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+
+	// optional exemplar, only exported for counters and histograms
+	Exemplar *MetricExemplar `json:"exemplar,omitempty"`
+}
+
+type MetricExemplar struct {
+	Labels prometheus.Labels `json:"labels"`
+
+	// optional exemplar value, row value is used if not set
+	Value *float64 `json:"value,omitempty"`
+
+	// optional exemplar timestamp
+	Timestamp *time.Time `json:"timestamp,omitempty"`
 }
 
 type MetricList struct {
 	List []MetricRow `json:"list"`
 	mux  *sync.Mutex
 
+	compact *compactMetricStore
+
+	duplicatePolicy string
+
+	collector *metricListCollector
+
 	metricsCache *cache.Cache
 }
 
@@ -40,10 +64,39 @@ func (m *MetricList) SetCache(instance *cache.Cache) {
 	m.metricsCache = instance
 }
 
+// EnableCompactStorage switches the list to compact storage with interned label strings
+//
+//	reduces memory usage for large lists, GetList has to build the label maps on each call
+//	so GaugeSet, CounterAdd and others should be used instead
+func (m *MetricList) EnableCompactStorage() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.compact != nil {
+		return
+	}
+
+	m.compact = newCompactMetricStore()
+	for _, row := range m.List {
+		m.compact.add(row)
+	}
+	m.List = nil
+}
+
+// IsCompactStorage returns true if compact storage is enabled
+func (m *MetricList) IsCompactStorage() bool {
+	return m.compact != nil
+}
+
 func (m *MetricList) append(row MetricRow) {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
+	if m.compact != nil {
+		m.compact.add(row)
+		return
+	}
+
 	if m.List == nil {
 		m.List = []MetricRow{}
 	}
@@ -60,7 +113,7 @@ func (m *MetricList) LoadFromCache(key string) bool {
 
 		if val, fetched := m.metricsCache.Get(key); fetched {
 			// loaded from cache
-			m.List = val.([]MetricRow)
+			m.setList(val.([]MetricRow))
 			return true
 		}
 	}
@@ -79,6 +132,18 @@ func (m *MetricList) Add(labels prometheus.Labels, value float64) {
 	m.append(MetricRow{Labels: labels, Value: value})
 }
 
+func (m *MetricList) AddRow(row MetricRow) {
+	m.append(row)
+}
+
+func (m *MetricList) AddWithTimestamp(labels prometheus.Labels, value float64, timestamp time.Time) {
+	m.append(MetricRow{Labels: labels, Value: value, Timestamp: &timestamp})
+}
+
+func (m *MetricList) AddWithExemplar(labels prometheus.Labels, value float64, exemplar prometheus.Labels) {
+	m.append(MetricRow{Labels: labels, Value: value, Exemplar: &MetricExemplar{Labels: exemplar}})
+}
+
 func (m *MetricList) AddInfo(labels prometheus.Labels) {
 	m.append(MetricRow{Labels: labels, Value: 1})
 }
@@ -125,13 +190,36 @@ func (m *MetricList) AddBool(labels prometheus.Labels, state bool) {
 func (m *MetricList) Reset() {
 	m.mux.Lock()
 	defer m.mux.Unlock()
+
+	if m.compact != nil {
+		m.compact = newCompactMetricStore()
+		return
+	}
+
 	m.List = []MetricRow{}
 }
 
+// setList replaces all rows of the list
+func (m *MetricList) setList(list []MetricRow) {
+	if m.compact != nil {
+		m.compact = newCompactMetricStore()
+		for _, row := range list {
+			m.compact.add(row)
+		}
+		return
+	}
+
+	m.List = list
+}
+
 func (m *MetricList) GetList() []MetricRow {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
+	if m.compact != nil {
+		return m.compact.list()
+	}
+
 	if m.List == nil {
 		m.List = []MetricRow{}
 	}
@@ -139,36 +227,120 @@ func (m *MetricList) GetList() []MetricRow {
 	return m.List
 }
 
+// forEach calls the callback for every row, with compact storage the labels are only valid during the callback
+func (m *MetricList) forEach(callback func(row MetricRow)) {
+	m.mux.Lock()
+	if m.compact != nil {
+		defer m.mux.Unlock()
+		m.compact.forEach(callback)
+		return
+	}
+	m.mux.Unlock()
+
+	for _, row := range m.GetList() {
+		callback(row)
+	}
+}
+
+// GaugeSet sets the values of the rows to the gauge vec
+//
+//	vecs don't support sample timestamps and gauges don't support exemplars, so both are not exported;
+//	lists used as prometheus.Collector (see SetDesc) export the timestamps as const metrics
 func (m *MetricList) GaugeSet(gauge *prometheus.GaugeVec) {
-	for _, metric := range m.GetList() {
+	m.forEach(func(metric MetricRow) {
 		gauge.With(metric.Labels).Set(metric.Value)
-	}
+	})
 }
 
 func (m *MetricList) GaugeSetInc(gauge *prometheus.GaugeVec) {
-	for _, metric := range m.GetList() {
+	m.forEach(func(metric MetricRow) {
 		if metricGauge, err := gauge.GetMetricWith(metric.Labels); err == nil {
 			metricGauge.Add(metric.Value)
 		} else {
 			panic(err)
 		}
-	}
+	})
 }
 
 func (m *MetricList) SummarySet(summary *prometheus.SummaryVec) {
-	for _, metric := range m.GetList() {
+	m.forEach(func(metric MetricRow) {
 		summary.With(metric.Labels).Observe(metric.Value)
-	}
+	})
 }
 
 func (m *MetricList) HistogramSet(histogram *prometheus.HistogramVec) {
-	for _, metric := range m.GetList() {
-		histogram.With(metric.Labels).Observe(metric.Value)
-	}
+	m.forEach(func(metric MetricRow) {
+		observer := histogram.With(metric.Labels)
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && metric.Exemplar != nil {
+			exemplarObserver.ObserveWithExemplar(metric.Value, metric.Exemplar.Labels)
+		} else {
+			observer.Observe(metric.Value)
+		}
+	})
 }
 
+// CounterAdd adds the values of the rows (with exemplar if set) to the counter vec
+//
+//	vecs don't support sample timestamps, lists used as prometheus.Collector (see SetDesc) export the timestamps as const metrics
 func (m *MetricList) CounterAdd(counter *prometheus.CounterVec) {
-	for _, metric := range m.GetList() {
-		counter.With(metric.Labels).Add(metric.Value)
+	m.forEach(func(metric MetricRow) {
+		metricCounter := counter.With(metric.Labels)
+		if exemplarAdder, ok := metricCounter.(prometheus.ExemplarAdder); ok && metric.Exemplar != nil {
+			exemplarAdder.AddWithExemplar(metric.Value, metric.Exemplar.Labels)
+		} else {
+			metricCounter.Add(metric.Value)
+		}
+	})
+}
+
+// MarshalJSON serializes the list, compact storage uses the same format
+func (m *MetricList) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteString(`{"list":[`)
+
+	var err error
+	first := true
+	m.forEach(func(row MetricRow) {
+		if err != nil {
+			return
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		var rowJson []byte
+		if rowJson, err = json.Marshal(row); err == nil {
+			buf.Write(rowJson)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(`]}`)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON deserializes the list, list is not changed if the serialized list is null
+func (m *MetricList) UnmarshalJSON(data []byte) error {
+	if m.mux == nil {
+		m.Init()
+	}
+
+	parsed := struct {
+		List []MetricRow `json:"list"`
+	}{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
 	}
+
+	if parsed.List != nil {
+		m.mux.Lock()
+		defer m.mux.Unlock()
+		m.setList(parsed.List)
+	}
+
+	return nil
 }
@@ -0,0 +1,193 @@
+package prometheus
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// MetricDesc describes a metric which is exported directly by a MetricList
+	MetricDesc struct {
+		Name        string
+		Help        string
+		LabelNames  []string
+		ConstLabels prometheus.Labels
+		ValueType   prometheus.ValueType
+	}
+
+	metricListCollector struct {
+		metricDesc MetricDesc
+		desc       *prometheus.Desc
+		labelNames []string
+		valueType  prometheus.ValueType
+
+		mux      sync.RWMutex
+		snapshot []constMetricRow
+	}
+
+	constMetricRow struct {
+		labelValues []string
+		value       float64
+		timestamp   *time.Time
+		exemplar    *MetricExemplar
+		err         error
+	}
+)
+
+// SetDesc enables the usage of the list as prometheus.Collector
+//
+//	metrics are exported as const metrics from the snapshot created by Publish,
+//	so there is no vec state which needs to be reset
+func (m *MetricList) SetDesc(desc MetricDesc) {
+	valueType := desc.ValueType
+	if valueType == 0 {
+		valueType = prometheus.GaugeValue
+	}
+
+	m.collector = &metricListCollector{
+		metricDesc: desc,
+		desc:       prometheus.NewDesc(desc.Name, desc.Help, desc.LabelNames, desc.ConstLabels),
+		labelNames: append([]string{}, desc.LabelNames...),
+		valueType:  valueType,
+	}
+}
+
+// IsCollector returns true if the list is used as prometheus.Collector (see SetDesc)
+func (m *MetricList) IsCollector() bool {
+	return m.collector != nil
+}
+
+// Publish creates the snapshot of the current rows which is exported on each scrape
+//
+//	rows with the same label set are merged according to the duplicate policy (last wins by default),
+//	rows with missing or unexpected labels and duplicates with DuplicatePolicyError are exported as invalid metrics
+func (m *MetricList) Publish() {
+	if m.collector == nil {
+		return
+	}
+
+	snapshot := []constMetricRow{}
+	rowIndex := map[string]int{}
+	m.forEach(func(row MetricRow) {
+		labelValues, err := m.collector.labelValues(row.Labels)
+		if err != nil {
+			snapshot = append(snapshot, constMetricRow{err: err})
+			return
+		}
+
+		snapshotRow := constMetricRow{
+			labelValues: labelValues,
+			value:       row.Value,
+			timestamp:   row.Timestamp,
+			exemplar:    row.Exemplar,
+		}
+
+		key := LabelSetKey(row.Labels)
+		idx, exists := rowIndex[key]
+		if !exists {
+			rowIndex[key] = len(snapshot)
+			snapshot = append(snapshot, snapshotRow)
+			return
+		}
+
+		switch m.duplicatePolicy {
+		case DuplicatePolicyFirstWins:
+		case DuplicatePolicySum:
+			snapshot[idx].value += row.Value
+		case DuplicatePolicyMax:
+			snapshot[idx].value = math.Max(snapshot[idx].value, row.Value)
+		case DuplicatePolicyError:
+			snapshot = append(snapshot, constMetricRow{err: fmt.Errorf(`duplicate series %v`, row.Labels)})
+		default:
+			snapshot[idx] = snapshotRow
+		}
+	})
+
+	m.collector.mux.Lock()
+	defer m.collector.mux.Unlock()
+	m.collector.snapshot = snapshot
+}
+
+// labelValues returns the values of the desc labels, the row has to contain exactly these labels
+func (c *metricListCollector) labelValues(labels prometheus.Labels) ([]string, error) {
+	labelValues := make([]string, len(c.labelNames))
+	for i, labelName := range c.labelNames {
+		labelValue, exists := labels[labelName]
+		if !exists {
+			return nil, fmt.Errorf(`label "%v" is missing in series %v`, labelName, labels)
+		}
+		labelValues[i] = labelValue
+	}
+
+	for _, labelName := range slices.Sorted(maps.Keys(labels)) {
+		if !slices.Contains(c.labelNames, labelName) {
+			return nil, fmt.Errorf(`unexpected label "%v" in series %v`, labelName, labels)
+		}
+	}
+
+	return labelValues, nil
+}
+
+// Describe implements prometheus.Collector
+func (m *MetricList) Describe(ch chan<- *prometheus.Desc) {
+	if m.collector != nil {
+		ch <- m.collector.desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (m *MetricList) Collect(ch chan<- prometheus.Metric) {
+	if m.collector == nil {
+		return
+	}
+
+	m.collector.mux.RLock()
+	defer m.collector.mux.RUnlock()
+
+	for _, row := range m.collector.snapshot {
+		ch <- m.collector.constMetric(row)
+	}
+}
+
+// constMetric builds the const metric (with timestamp and exemplar) of the snapshot row
+func (c *metricListCollector) constMetric(row constMetricRow) prometheus.Metric {
+	if row.err != nil {
+		return prometheus.NewInvalidMetric(c.desc, row.err)
+	}
+
+	metric, err := prometheus.NewConstMetric(c.desc, c.valueType, row.value, row.labelValues...)
+	if err != nil {
+		return prometheus.NewInvalidMetric(c.desc, err)
+	}
+
+	// exemplars are only supported by counters
+	if row.exemplar != nil && c.valueType == prometheus.CounterValue {
+		exemplar := prometheus.Exemplar{
+			Labels: row.exemplar.Labels,
+			Value:  row.value,
+		}
+		if row.exemplar.Value != nil {
+			exemplar.Value = *row.exemplar.Value
+		}
+		if row.exemplar.Timestamp != nil {
+			exemplar.Timestamp = *row.exemplar.Timestamp
+		}
+
+		metric, err = prometheus.NewMetricWithExemplars(metric, exemplar)
+		if err != nil {
+			return prometheus.NewInvalidMetric(c.desc, err)
+		}
+	}
+
+	if row.timestamp != nil {
+		metric = prometheus.NewMetricWithTimestamp(*row.timestamp, metric)
+	}
+
+	return metric
+}
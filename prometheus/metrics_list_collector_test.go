@@ -0,0 +1,183 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_MetricsListCollector(t *testing.T) {
+	m := NewMetricsList()
+	m.SetDesc(MetricDesc{
+		Name:       "test_resource_info",
+		Help:       "Test resource info",
+		LabelNames: []string{"resourceID", "location"},
+	})
+
+	m.AddInfo(prometheus.Labels{"resourceID": "/foo", "location": "westeurope"})
+	m.AddInfo(prometheus.Labels{"resourceID": "/bar", "location": "northeurope"})
+
+	// nothing published yet
+	if count := testutil.CollectAndCount(m); count != 0 {
+		t.Errorf("expected no metrics before publish, got %v", count)
+	}
+
+	m.Publish()
+	m.Reset()
+
+	expected := `
+# HELP test_resource_info Test resource info
+# TYPE test_resource_info gauge
+test_resource_info{location="northeurope",resourceID="/bar"} 1
+test_resource_info{location="westeurope",resourceID="/foo"} 1
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(m); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_MetricsListCollectorCounter(t *testing.T) {
+	m := NewMetricsList()
+	m.EnableCompactStorage()
+	m.SetDesc(MetricDesc{
+		Name:       "test_requests_total",
+		Help:       "Test requests",
+		LabelNames: []string{"status"},
+		ValueType:  prometheus.CounterValue,
+	})
+
+	m.Add(prometheus.Labels{"status": "200"}, 12)
+	m.Publish()
+
+	expected := `
+# HELP test_requests_total Test requests
+# TYPE test_requests_total counter
+test_requests_total{status="200"} 12
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_MetricsListCollectorTimestampExemplar(t *testing.T) {
+	m := NewMetricsList()
+	m.SetDesc(MetricDesc{
+		Name:       "test_events_total",
+		Help:       "Test events",
+		LabelNames: []string{"type"},
+		ValueType:  prometheus.CounterValue,
+	})
+
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m.AddRow(MetricRow{
+		Labels:    prometheus.Labels{"type": "error"},
+		Value:     3,
+		Timestamp: &timestamp,
+		Exemplar:  &MetricExemplar{Labels: prometheus.Labels{"resourceID": "/foo"}, Timestamp: &timestamp},
+	})
+
+	// timestamp and exemplar must survive the cache format
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Reset()
+	if err := json.Unmarshal(data, m); err != nil {
+		t.Fatal(err)
+	}
+	m.Publish()
+
+	metricChannel := make(chan prometheus.Metric, 1)
+	m.Collect(metricChannel)
+	close(metricChannel)
+
+	metric := dto.Metric{}
+	if err := (<-metricChannel).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+
+	if metric.GetTimestampMs() != timestamp.UnixMilli() {
+		t.Errorf("expected timestamp %v, got %v", timestamp.UnixMilli(), metric.GetTimestampMs())
+	}
+
+	exemplar := metric.GetCounter().GetExemplar()
+	if exemplar == nil || exemplar.GetValue() != 3 || len(exemplar.GetLabel()) != 1 || exemplar.GetLabel()[0].GetValue() != "/foo" {
+		t.Errorf("unexpected exemplar: %v", exemplar)
+	}
+}
+
+func Test_MetricsListCollectorDuplicates(t *testing.T) {
+	m := NewMetricsList()
+	m.SetDesc(MetricDesc{
+		Name:       "test_requests_total",
+		Help:       "Test requests",
+		LabelNames: []string{"status"},
+		ValueType:  prometheus.CounterValue,
+	})
+	m.SetDuplicatePolicy(DuplicatePolicySum)
+
+	m.Add(prometheus.Labels{"status": "200"}, 1)
+	m.Add(prometheus.Labels{"status": "200"}, 2)
+	m.Publish()
+
+	expected := `
+# HELP test_requests_total Test requests
+# TYPE test_requests_total counter
+test_requests_total{status="200"} 3
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	// last wins without duplicate policy
+	m.SetDuplicatePolicy(DuplicatePolicyNone)
+	m.Publish()
+
+	expected = `
+# HELP test_requests_total Test requests
+# TYPE test_requests_total counter
+test_requests_total{status="200"} 2
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	m.SetDuplicatePolicy(DuplicatePolicyError)
+	m.Publish()
+
+	if err := testutil.CollectAndCompare(m, strings.NewReader(expected)); err == nil || !strings.Contains(err.Error(), "duplicate series") {
+		t.Errorf("expected duplicate series error, got %v", err)
+	}
+}
+
+func Test_MetricsListCollectorInvalidLabels(t *testing.T) {
+	invalid := map[string]prometheus.Labels{
+		`label "location" is missing`:  {"resourceID": "/foo"},
+		`unexpected label "tag_owner"`: {"resourceID": "/foo", "location": "westeurope", "tag_owner": "foo"},
+	}
+
+	for expectedErr, labels := range invalid {
+		m := NewMetricsList()
+		m.SetDesc(MetricDesc{
+			Name:       "test_resource_info",
+			Help:       "Test resource info",
+			LabelNames: []string{"resourceID", "location"},
+		})
+		m.AddInfo(labels)
+		m.Publish()
+
+		if err := testutil.CollectAndCompare(m, strings.NewReader("")); err == nil || !strings.Contains(err.Error(), expectedErr) {
+			t.Errorf("expected error %q, got %v", expectedErr, err)
+		}
+	}
+}
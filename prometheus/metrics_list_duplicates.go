@@ -0,0 +1,100 @@
+package prometheus
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DuplicatePolicyNone      = ""
+	DuplicatePolicyLastWins  = "last-wins"
+	DuplicatePolicyFirstWins = "first-wins"
+	DuplicatePolicySum       = "sum"
+	DuplicatePolicyMax       = "max"
+	DuplicatePolicyError     = "error"
+)
+
+type (
+	// DuplicateResult contains the number of detected duplicate rows and an example label set
+	DuplicateResult struct {
+		Count   int
+		Example prometheus.Labels
+	}
+)
+
+// SetDuplicatePolicy sets the policy how rows with the same label set are handled by Deduplicate
+//
+//	DuplicatePolicyNone (default) disables the detection
+func (m *MetricList) SetDuplicatePolicy(policy string) {
+	switch policy {
+	case DuplicatePolicyNone:
+	case DuplicatePolicyLastWins:
+	case DuplicatePolicyFirstWins:
+	case DuplicatePolicySum:
+	case DuplicatePolicyMax:
+	case DuplicatePolicyError:
+	default:
+		panic(fmt.Errorf(`unknown duplicate policy "%s"`, policy))
+	}
+
+	m.duplicatePolicy = policy
+}
+
+// GetDuplicatePolicy returns the duplicate policy
+func (m *MetricList) GetDuplicatePolicy() string {
+	return m.duplicatePolicy
+}
+
+// Deduplicate detects rows with the same label set and merges them according to the duplicate policy
+//
+//	with DuplicatePolicyError the list is not modified and an error is returned if duplicates are found
+func (m *MetricList) Deduplicate() (result DuplicateResult, err error) {
+	if m.duplicatePolicy == DuplicatePolicyNone {
+		return
+	}
+
+	list := m.GetList()
+
+	rowIndex := make(map[string]int, len(list))
+	dedupList := make([]MetricRow, 0, len(list))
+	for _, row := range list {
+		key := LabelSetKey(row.Labels)
+
+		idx, exists := rowIndex[key]
+		if !exists {
+			rowIndex[key] = len(dedupList)
+			dedupList = append(dedupList, row)
+			continue
+		}
+
+		result.Count++
+		if result.Example == nil {
+			result.Example = row.Labels
+		}
+
+		switch m.duplicatePolicy {
+		case DuplicatePolicyLastWins:
+			dedupList[idx] = row
+		case DuplicatePolicySum:
+			dedupList[idx].Value += row.Value
+		case DuplicatePolicyMax:
+			dedupList[idx].Value = math.Max(dedupList[idx].Value, row.Value)
+		}
+	}
+
+	if result.Count == 0 {
+		return
+	}
+
+	if m.duplicatePolicy == DuplicatePolicyError {
+		return result, fmt.Errorf(`found %v duplicate series, eg. %v`, result.Count, result.Example)
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.setList(dedupList)
+
+	return
+}
@@ -0,0 +1,77 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func Test_MetricsListDeduplicate(t *testing.T) {
+	expected := map[string]float64{
+		DuplicatePolicyLastWins:  3,
+		DuplicatePolicyFirstWins: 1,
+		DuplicatePolicySum:       9,
+		DuplicatePolicyMax:       5,
+	}
+
+	for policy, expectedValue := range expected {
+		m := duplicateTestMetricList()
+		m.SetDuplicatePolicy(policy)
+
+		result, err := m.Deduplicate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Count != 2 {
+			t.Errorf("%v: expected 2 duplicates, got %v", policy, result.Count)
+		}
+
+		if result.Example["id"] != "a" {
+			t.Errorf("%v: expected example with id a, got %v", policy, result.Example)
+		}
+
+		expectListCount(t, m, 2)
+		for _, row := range m.GetList() {
+			if row.Labels["id"] == "a" && row.Value != expectedValue {
+				t.Errorf("%v: expected value %v, got %v", policy, expectedValue, row.Value)
+			}
+		}
+	}
+}
+
+func Test_MetricsListDeduplicateError(t *testing.T) {
+	m := duplicateTestMetricList()
+	m.SetDuplicatePolicy(DuplicatePolicyError)
+
+	if _, err := m.Deduplicate(); err == nil {
+		t.Errorf("expected error for duplicate series")
+	}
+	expectListCount(t, m, 4)
+
+	// detection disabled
+	m = duplicateTestMetricList()
+	if result, err := m.Deduplicate(); err != nil || result.Count != 0 {
+		t.Errorf("expected no detection without duplicate policy")
+	}
+	expectListCount(t, m, 4)
+}
+
+func duplicateTestMetricList() *MetricList {
+	m := NewMetricsList()
+	m.Add(prometheus.Labels{"id": "a", "location": "westeurope"}, 1)
+	m.Add(prometheus.Labels{"location": "westeurope", "id": "a"}, 5)
+	m.Add(prometheus.Labels{"id": "b", "location": "westeurope"}, 2)
+	m.Add(prometheus.Labels{"id": "a", "location": "westeurope"}, 3)
+	return m
+}
+
+func Test_LabelSetKey(t *testing.T) {
+	if LabelSetKey(prometheus.Labels{"a": "1", "b": "2"}) != LabelSetKey(prometheus.Labels{"b": "2", "a": "1"}) {
+		t.Errorf("label key must not depend on label order")
+	}
+
+	if LabelSetKey(prometheus.Labels{"a": "1;b=2"}) == LabelSetKey(prometheus.Labels{"a": "1", "b": "2"}) {
+		t.Errorf("label key must not collide")
+	}
+}
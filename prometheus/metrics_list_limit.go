@@ -0,0 +1,220 @@
+package prometheus
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	SeriesOverflowLabelValue = "__overflow__"
+)
+
+type (
+	// SeriesLimitResult contains the number of series (before the limit was applied) and the number of series
+	// which were dropped or folded into the overflow series
+	SeriesLimitResult struct {
+		Series  int
+		Limited int
+	}
+)
+
+// LimitSeries keeps the first limit series of the list (limit <= 0 disables the limit, series are only counted)
+//
+//	if overflowLabel is set the remaining series are summed up into an overflow series
+//	where all label values are empty and the overflowLabel is set to SeriesOverflowLabelValue,
+//	otherwise they are dropped
+//
+//	summing up is only meaningful for gauge and counter values, rows of histograms and summaries
+//	are observations and should be dropped (empty overflowLabel)
+func (m *MetricList) LimitSeries(limit int, overflowLabel string) (result SeriesLimitResult) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	var list []MetricRow
+	if m.compact != nil {
+		list = m.compact.list()
+	} else {
+		list = m.List
+	}
+
+	if limit <= 0 {
+		// only count series, rows with the same label set are one series
+		result.Series = countMetricSeries(list)
+		return
+	}
+
+	list, result = limitMetricRows(list, limit, overflowLabel)
+	if result.Limited > 0 {
+		m.setList(list)
+	}
+
+	return
+}
+
+// LimitSeries keeps the first limit series of the list (see MetricList.LimitSeries)
+//
+//	the order of the hashed list is not stable, so it is random which series are kept
+func (m *HashedMetricList) LimitSeries(limit int, overflowLabel string) (result SeriesLimitResult) {
+	if limit <= 0 {
+		for _, stripe := range m.stripes {
+			stripe.mux.Lock()
+			stripe.forEach(func(entry *hashedMetricEntry) {
+				result.Series++
+			})
+			stripe.mux.Unlock()
+		}
+		return
+	}
+
+	list := m.GetList()
+
+	list, result = limitMetricRows(list, limit, overflowLabel)
+	if result.Limited > 0 {
+		m.Reset()
+		m.setList(list)
+	}
+
+	return
+}
+
+// LimitSeries keeps the first limit series of the list (see MetricList.LimitSeries)
+//
+//	series are only folded into an overflow series if overflowLabel is part of the schema, otherwise they are dropped
+func (m *TypedMetricList[T]) LimitSeries(limit int, overflowLabel string) (result SeriesLimitResult) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	labelValues := make([]string, len(m.schema.labelFields))
+
+	if limit <= 0 {
+		// only count series, rows with the same label set are one series
+		seriesIndex := map[string]struct{}{}
+		for i := range m.List {
+			seriesIndex[m.seriesKey(reflect.ValueOf(&m.List[i]).Elem(), labelValues)] = struct{}{}
+		}
+		result.Series = len(seriesIndex)
+		return
+	}
+
+	overflowField := -1
+	for n, labelName := range m.schema.labelNames {
+		if labelName == overflowLabel {
+			overflowField = m.schema.labelFields[n]
+		}
+	}
+
+	var overflow *T
+	seriesIndex := map[string]bool{}
+	list := make([]T, 0, len(m.List))
+	for i := range m.List {
+		row := reflect.ValueOf(&m.List[i]).Elem()
+		key := m.seriesKey(row, labelValues)
+		if _, exists := seriesIndex[key]; !exists {
+			seriesIndex[key] = len(seriesIndex) < limit
+			result.Series++
+			if !seriesIndex[key] {
+				result.Limited++
+			}
+		}
+
+		if seriesIndex[key] {
+			list = append(list, m.List[i])
+			continue
+		}
+
+		if overflowField == -1 {
+			continue
+		}
+
+		if overflow == nil {
+			overflow = new(T)
+			overflowRow := reflect.ValueOf(overflow).Elem()
+			if err := typedMetricFieldFromString(overflowRow.Field(overflowField), SeriesOverflowLabelValue); err != nil {
+				// marker cannot be stored in field (eg. numeric label), drop series instead
+				overflowField = -1
+				overflow = nil
+				continue
+			}
+		}
+
+		overflowRow := reflect.ValueOf(overflow).Elem()
+		typedMetricFieldFromFloat64(
+			overflowRow.Field(m.schema.valueField),
+			typedMetricFieldToFloat64(overflowRow.Field(m.schema.valueField))+typedMetricFieldToFloat64(row.Field(m.schema.valueField)),
+		)
+	}
+
+	if result.Limited > 0 {
+		if overflow != nil {
+			list = append(list, *overflow)
+		}
+		m.List = list
+	}
+
+	return
+}
+
+// seriesKey returns the key of the label values of the row, labelValues is used as buffer
+func (m *TypedMetricList[T]) seriesKey(row reflect.Value, labelValues []string) string {
+	for n, field := range m.schema.labelFields {
+		labelValues[n] = typedMetricFieldToString(row.Field(field))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+// countMetricSeries returns the number of unique label sets of the rows
+func countMetricSeries(list []MetricRow) int {
+	seriesIndex := make(map[string]struct{}, len(list))
+	for _, row := range list {
+		seriesIndex[LabelSetKey(row.Labels)] = struct{}{}
+	}
+
+	return len(seriesIndex)
+}
+
+// limitMetricRows keeps the rows of the first limit series and drops or folds the other rows
+func limitMetricRows(list []MetricRow, limit int, overflowLabel string) ([]MetricRow, SeriesLimitResult) {
+	result := SeriesLimitResult{}
+
+	seriesIndex := make(map[string]bool, len(list))
+	overflowIndex := map[string]int{}
+	ret := make([]MetricRow, 0, len(list))
+	for _, row := range list {
+		key := LabelSetKey(row.Labels)
+		if _, exists := seriesIndex[key]; !exists {
+			seriesIndex[key] = len(seriesIndex) < limit
+			result.Series++
+			if !seriesIndex[key] {
+				result.Limited++
+			}
+		}
+
+		if seriesIndex[key] {
+			ret = append(ret, row)
+			continue
+		}
+
+		if overflowLabel == "" {
+			continue
+		}
+
+		labels := make(prometheus.Labels, len(row.Labels)+1)
+		for labelName := range row.Labels {
+			labels[labelName] = ""
+		}
+		labels[overflowLabel] = SeriesOverflowLabelValue
+
+		overflowKey := LabelSetKey(labels)
+		if idx, exists := overflowIndex[overflowKey]; exists {
+			ret[idx].Value += row.Value
+		} else {
+			overflowIndex[overflowKey] = len(ret)
+			ret = append(ret, MetricRow{Labels: labels, Value: row.Value})
+		}
+	}
+
+	return ret, result
+}
@@ -0,0 +1,101 @@
+package prometheus
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func Test_MetricsListLimitSeries(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		m := NewMetricsList()
+		if compact {
+			m.EnableCompactStorage()
+		}
+
+		for i := 0; i < 5; i++ {
+			m.Add(prometheus.Labels{"resourceID": fmt.Sprintf("/res%d", i), "tag_build": fmt.Sprintf("%d", i)}, 1)
+		}
+
+		result := m.LimitSeries(3, "")
+		if result.Series != 5 || result.Limited != 2 {
+			t.Errorf("unexpected limit result: %v", result)
+		}
+
+		if list := m.GetList(); len(list) != 3 || list[2].Labels["resourceID"] != "/res2" {
+			t.Errorf("unexpected limited list: %v", list)
+		}
+	}
+}
+
+func Test_MetricsListLimitSeriesOverflow(t *testing.T) {
+	m := NewMetricsList()
+	for i := 0; i < 5; i++ {
+		m.Add(prometheus.Labels{"resourceID": fmt.Sprintf("/res%d", i), "tag_build": fmt.Sprintf("%d", i)}, float64(i))
+	}
+
+	result := m.LimitSeries(2, "resourceID")
+	if result.Series != 5 || result.Limited != 3 {
+		t.Errorf("unexpected limit result: %v", result)
+	}
+
+	list := m.GetList()
+	if len(list) != 3 {
+		t.Fatalf("expected 2 series and overflow series, got %v", list)
+	}
+
+	overflow := list[2]
+	if overflow.Labels["resourceID"] != SeriesOverflowLabelValue || overflow.Labels["tag_build"] != "" || overflow.Value != 2+3+4 {
+		t.Errorf("unexpected overflow series: %v", overflow)
+	}
+
+	// no limit only counts
+	if result := m.LimitSeries(0, ""); result.Series != 3 || result.Limited != 0 {
+		t.Errorf("unexpected count result: %v", result)
+	}
+}
+
+func Test_HashedMetricsListLimitSeries(t *testing.T) {
+	m := NewHashedMetricsList()
+	for i := 0; i < 5; i++ {
+		m.Inc(prometheus.Labels{"resourceID": fmt.Sprintf("/res%d", i)})
+	}
+
+	result := m.LimitSeries(3, "resourceID")
+	if result.Series != 5 || result.Limited != 2 {
+		t.Errorf("unexpected limit result: %v", result)
+	}
+
+	expectHashedListValue(t, m, prometheus.Labels{"resourceID": SeriesOverflowLabelValue}, 2)
+	if count := m.LimitSeries(0, "").Series; count != 4 {
+		t.Errorf("expected 4 series, got %v", count)
+	}
+}
+
+func Test_TypedMetricsListLimitSeries(t *testing.T) {
+	type row struct {
+		ResourceID string `label:"resourceID"`
+		Value      float64
+	}
+
+	m := NewTypedMetricList[row]()
+	for i := 0; i < 5; i++ {
+		m.Add(row{ResourceID: fmt.Sprintf("/res%d", i), Value: 2})
+	}
+
+	result := m.LimitSeries(4, "resourceID")
+	if result.Series != 5 || result.Limited != 1 {
+		t.Errorf("unexpected limit result: %v", result)
+	}
+
+	list := m.GetList()
+	if len(list) != 5 || list[4].ResourceID != SeriesOverflowLabelValue || list[4].Value != 2 {
+		t.Errorf("unexpected limited list: %v", list)
+	}
+
+	m.LimitSeries(2, "")
+	if list := m.GetList(); len(list) != 2 {
+		t.Errorf("unexpected limited list: %v", list)
+	}
+}
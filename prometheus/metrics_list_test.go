@@ -1,11 +1,15 @@
 package prometheus
 
 import (
+	"encoding/json"
+	"fmt"
+	"runtime"
 	"testing"
 	"time"
 
 	cache "github.com/patrickmn/go-cache"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func Test_MetricsList(t *testing.T) {
@@ -133,3 +137,81 @@ func expectMetricRowValue(t *testing.T, m MetricRow, expectedValue float64) {
 		t.Errorf("Expected metric value: %v  Actual metric value: %v", expectedValue, m.Value)
 	}
 }
+
+func Test_MetricsListCompact(t *testing.T) {
+	m := NewMetricsList()
+	m.EnableCompactStorage()
+	metricsListGenerateMetrics(t, m)
+	metricsListTestList(t, m)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// compact storage must use the same serialization format
+	m2 := NewMetricsList()
+	if err := json.Unmarshal(data, m2); err != nil {
+		t.Fatal(err)
+	}
+	expectListCount(t, m2, 5)
+	metricsListTestList(t, m2)
+
+	m3 := NewMetricsList()
+	m3.EnableCompactStorage()
+	if err := json.Unmarshal(data, m3); err != nil {
+		t.Fatal(err)
+	}
+	expectListCount(t, m3, 5)
+	metricsListTestList(t, m3)
+
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_compact"}, []string{"key"})
+	m3.GaugeSet(gauge)
+	if val := testutil.ToFloat64(gauge.WithLabelValues("custom")); val != 123 {
+		t.Errorf("Expected metric value: %v  Actual metric value: %v", 123, val)
+	}
+}
+
+func BenchmarkMetricsListAdd(b *testing.B) {
+	b.Run("default", func(b *testing.B) {
+		benchmarkMetricsListAdd(b, NewMetricsList())
+	})
+
+	b.Run("compact", func(b *testing.B) {
+		m := NewMetricsList()
+		m.EnableCompactStorage()
+		benchmarkMetricsListAdd(b, m)
+	})
+}
+
+func benchmarkMetricsListAdd(b *testing.B, m *MetricList) {
+	b.Helper()
+	b.ReportAllocs()
+
+	heapBefore := benchmarkHeapInUse()
+	for i := 0; i < b.N; i++ {
+		m.Add(benchmarkResourceLabels(i), float64(i))
+	}
+	b.ReportMetric(float64(benchmarkHeapInUse()-heapBefore)/float64(b.N), "heap-B/row")
+	runtime.KeepAlive(m)
+}
+
+func benchmarkResourceLabels(i int) prometheus.Labels {
+	subscriptionID := fmt.Sprintf("00000000-0000-0000-0000-%012d", i%10)
+	resourceGroup := fmt.Sprintf("resourcegroup-%d", i%100)
+
+	return prometheus.Labels{
+		"subscriptionID": subscriptionID,
+		"resourceGroup":  resourceGroup,
+		"resourceID":     fmt.Sprintf("/subscriptions/%s/resourcegroups/%s/providers/microsoft.compute/virtualmachines/vm-%d", subscriptionID, resourceGroup, i),
+		"location":       fmt.Sprintf("location-%d", i%5),
+		"provider":       fmt.Sprintf("microsoft.%s", "compute"),
+	}
+}
+
+func benchmarkHeapInUse() uint64 {
+	runtime.GC()
+	stats := runtime.MemStats{}
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
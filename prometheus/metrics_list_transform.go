@@ -0,0 +1,262 @@
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	RelabelActionReplace   = "replace"
+	RelabelActionKeep      = "keep"
+	RelabelActionDrop      = "drop"
+	RelabelActionLabelMap  = "labelmap"
+	RelabelActionLabelDrop = "labeldrop"
+	RelabelActionLabelKeep = "labelkeep"
+
+	RelabelDefaultSeparator   = ";"
+	RelabelDefaultRegexp      = "(.*)"
+	RelabelDefaultReplacement = "$1"
+
+	AggregationSum   = "sum"
+	AggregationMin   = "min"
+	AggregationMax   = "max"
+	AggregationCount = "count"
+	AggregationAvg   = "avg"
+)
+
+type (
+	// RelabelConfig defines a relabel step, modelled on Prometheus relabel_configs
+	RelabelConfig struct {
+		SourceLabels []string `json:"sourceLabels"`
+		Separator    *string  `json:"separator"`
+		Regex        string   `json:"regex"`
+		TargetLabel  string   `json:"targetLabel"`
+		Replacement  *string  `json:"replacement"`
+		Action       string   `json:"action"`
+
+		parsedRegexp *regexp.Regexp
+	}
+
+	aggregationGroup struct {
+		labels prometheus.Labels
+		value  float64
+		count  int
+	}
+)
+
+// Validate checks the relabel config and compiles the regexp
+func (c *RelabelConfig) Validate() error {
+	switch c.GetAction() {
+	case RelabelActionReplace:
+		if c.TargetLabel == "" {
+			return errors.New("relabel action \"replace\" needs targetLabel")
+		}
+	case RelabelActionKeep, RelabelActionDrop:
+		if len(c.SourceLabels) == 0 {
+			return fmt.Errorf("relabel action \"%v\" needs sourceLabels", c.GetAction())
+		}
+	case RelabelActionLabelMap, RelabelActionLabelDrop, RelabelActionLabelKeep:
+	default:
+		return fmt.Errorf("relabel action \"%v\" not supported", c.Action)
+	}
+
+	regex := c.Regex
+	if regex == "" {
+		regex = RelabelDefaultRegexp
+	}
+
+	parsedRegexp, err := regexp.Compile("^(?:" + regex + ")$")
+	if err != nil {
+		return fmt.Errorf("relabel regexp \"%v\" is invalid: %w", c.Regex, err)
+	}
+	c.parsedRegexp = parsedRegexp
+
+	return nil
+}
+
+// GetAction returns the relabel action (replace if not set)
+func (c *RelabelConfig) GetAction() (ret string) {
+	ret = strings.ToLower(c.Action)
+	if ret == "" {
+		ret = RelabelActionReplace
+	}
+	return
+}
+
+func (c *RelabelConfig) getSeparator() string {
+	if c.Separator != nil {
+		return *c.Separator
+	}
+	return RelabelDefaultSeparator
+}
+
+func (c *RelabelConfig) getReplacement() string {
+	if c.Replacement != nil {
+		return *c.Replacement
+	}
+	return RelabelDefaultReplacement
+}
+
+// apply applies the relabel config to the labels, returns false if the row should be dropped
+func (c *RelabelConfig) apply(labels prometheus.Labels) bool {
+	sourceValues := make([]string, len(c.SourceLabels))
+	for i, labelName := range c.SourceLabels {
+		sourceValues[i] = labels[labelName]
+	}
+	sourceValue := strings.Join(sourceValues, c.getSeparator())
+
+	switch c.GetAction() {
+	case RelabelActionReplace:
+		indexes := c.parsedRegexp.FindStringSubmatchIndex(sourceValue)
+		if indexes == nil {
+			return true
+		}
+
+		target := string(c.parsedRegexp.ExpandString(nil, c.TargetLabel, sourceValue, indexes))
+		if !IsValidLabelName(target) {
+			return true
+		}
+
+		value := string(c.parsedRegexp.ExpandString(nil, c.getReplacement(), sourceValue, indexes))
+		if value == "" {
+			delete(labels, target)
+		} else {
+			labels[target] = value
+		}
+	case RelabelActionKeep:
+		return c.parsedRegexp.MatchString(sourceValue)
+	case RelabelActionDrop:
+		return !c.parsedRegexp.MatchString(sourceValue)
+	case RelabelActionLabelMap:
+		for labelName, labelValue := range maps.Clone(labels) {
+			if c.parsedRegexp.MatchString(labelName) {
+				labels[c.parsedRegexp.ReplaceAllString(labelName, c.getReplacement())] = labelValue
+			}
+		}
+	case RelabelActionLabelDrop:
+		maps.DeleteFunc(labels, func(labelName, _ string) bool {
+			return c.parsedRegexp.MatchString(labelName)
+		})
+	case RelabelActionLabelKeep:
+		maps.DeleteFunc(labels, func(labelName, _ string) bool {
+			return !c.parsedRegexp.MatchString(labelName)
+		})
+	}
+
+	return true
+}
+
+// Filter returns a new metric list with all rows for which the callback returns true
+func (m *MetricList) Filter(callback func(row MetricRow) bool) *MetricList {
+	ret := NewMetricsList()
+	m.forEach(func(row MetricRow) {
+		if callback(row) {
+			row.Labels = maps.Clone(row.Labels)
+			ret.append(row)
+		}
+	})
+	return ret
+}
+
+// Relabel returns a new metric list with the relabel configs applied on all rows
+func (m *MetricList) Relabel(configs ...RelabelConfig) (*MetricList, error) {
+	for i := range configs {
+		if err := configs[i].Validate(); err != nil {
+			return nil, fmt.Errorf("relabel config %v: %w", i, err)
+		}
+	}
+
+	ret := NewMetricsList()
+	m.forEach(func(row MetricRow) {
+		row.Labels = maps.Clone(row.Labels)
+		for i := range configs {
+			if !configs[i].apply(row.Labels) {
+				return
+			}
+		}
+		ret.append(row)
+	})
+
+	return ret, nil
+}
+
+// Aggregate returns a new metric list with rows grouped by the passed labels and aggregated values
+func (m *MetricList) Aggregate(aggregation string, by ...string) (*MetricList, error) {
+	switch aggregation {
+	case AggregationSum, AggregationMin, AggregationMax, AggregationCount, AggregationAvg:
+	default:
+		return nil, fmt.Errorf("aggregation \"%v\" not supported", aggregation)
+	}
+
+	groupList := []*aggregationGroup{}
+	groupIndex := map[string]*aggregationGroup{}
+
+	groupKey := strings.Builder{}
+	m.forEach(func(row MetricRow) {
+		groupKey.Reset()
+		for _, labelName := range by {
+			groupKey.WriteString(row.Labels[labelName])
+			groupKey.WriteByte(0xff)
+		}
+
+		group, exists := groupIndex[groupKey.String()]
+		if !exists {
+			group = &aggregationGroup{labels: prometheus.Labels{}}
+			for _, labelName := range by {
+				group.labels[labelName] = row.Labels[labelName]
+			}
+
+			switch aggregation {
+			case AggregationMin:
+				group.value = math.Inf(1)
+			case AggregationMax:
+				group.value = math.Inf(-1)
+			}
+
+			groupIndex[groupKey.String()] = group
+			groupList = append(groupList, group)
+		}
+
+		group.count++
+		switch aggregation {
+		case AggregationSum, AggregationAvg:
+			group.value += row.Value
+		case AggregationMin:
+			group.value = math.Min(group.value, row.Value)
+		case AggregationMax:
+			group.value = math.Max(group.value, row.Value)
+		}
+	})
+
+	ret := NewMetricsList()
+	for _, group := range groupList {
+		value := group.value
+		switch aggregation {
+		case AggregationCount:
+			value = float64(group.count)
+		case AggregationAvg:
+			value = group.value / float64(group.count)
+		}
+		ret.Add(group.labels, value)
+	}
+
+	return ret, nil
+}
+
+// MergeMetricLists returns a new metric list with the rows of all passed lists
+func MergeMetricLists(lists ...*MetricList) *MetricList {
+	ret := NewMetricsList()
+	for _, list := range lists {
+		list.forEach(func(row MetricRow) {
+			row.Labels = maps.Clone(row.Labels)
+			ret.append(row)
+		})
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func transformTestMetricList() *MetricList {
+	m := NewMetricsList()
+	m.Add(prometheus.Labels{"resourceID": "/subscriptions/a/vm1", "location": "westeurope", "tag_owner": "foo"}, 1)
+	m.Add(prometheus.Labels{"resourceID": "/subscriptions/a/vm2", "location": "westeurope", "tag_owner": "bar"}, 3)
+	m.Add(prometheus.Labels{"resourceID": "/subscriptions/b/vm3", "location": "northeurope", "tag_owner": "foo"}, 5)
+	return m
+}
+
+func Test_MetricsListRelabel(t *testing.T) {
+	m := transformTestMetricList()
+
+	replacement := "$1"
+	result, err := m.Relabel(
+		RelabelConfig{Action: RelabelActionDrop, SourceLabels: []string{"location"}, Regex: "north.*"},
+		RelabelConfig{SourceLabels: []string{"resourceID"}, Regex: "/subscriptions/([^/]+)/.*", TargetLabel: "subscriptionID", Replacement: &replacement},
+		RelabelConfig{Action: RelabelActionLabelMap, Regex: "tag_(.+)", Replacement: &replacement},
+		RelabelConfig{Action: RelabelActionLabelDrop, Regex: "tag_.+"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectListCount(t, result, 2)
+	for _, row := range result.GetList() {
+		expectMetricRowLabel(t, row, "subscriptionID", "a")
+		expectMetricRowLabel(t, row, "location", "westeurope")
+		if _, exists := row.Labels["tag_owner"]; exists {
+			t.Errorf("label tag_owner should be dropped")
+		}
+		if _, exists := row.Labels["owner"]; !exists {
+			t.Errorf("label owner should be mapped")
+		}
+	}
+
+	// source list must not be modified
+	expectListCount(t, m, 3)
+	if _, exists := m.GetList()[0].Labels["subscriptionID"]; exists {
+		t.Errorf("source list should not be modified")
+	}
+
+	if _, err := m.Relabel(RelabelConfig{Action: "unknown"}); err == nil {
+		t.Errorf("expected error for unknown relabel action")
+	}
+}
+
+func Test_MetricsListAggregate(t *testing.T) {
+	m := transformTestMetricList()
+
+	expected := map[string]map[string]float64{
+		AggregationSum:   {"westeurope": 4, "northeurope": 5},
+		AggregationMin:   {"westeurope": 1, "northeurope": 5},
+		AggregationMax:   {"westeurope": 3, "northeurope": 5},
+		AggregationCount: {"westeurope": 2, "northeurope": 1},
+		AggregationAvg:   {"westeurope": 2, "northeurope": 5},
+	}
+
+	for aggregation, expectedValues := range expected {
+		result, err := m.Aggregate(aggregation, "location")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectListCount(t, result, 2)
+		for _, row := range result.GetList() {
+			if len(row.Labels) != 1 {
+				t.Errorf("%v: expected only location label, got %v", aggregation, row.Labels)
+			}
+			if expectedValue := expectedValues[row.Labels["location"]]; row.Value != expectedValue {
+				t.Errorf("%v: expected value %v for %v, got %v", aggregation, expectedValue, row.Labels["location"], row.Value)
+			}
+		}
+	}
+}
+
+func Test_MetricsListFilterMerge(t *testing.T) {
+	m := transformTestMetricList()
+
+	filtered := m.Filter(func(row MetricRow) bool {
+		return row.Labels["tag_owner"] == "foo"
+	})
+	expectListCount(t, filtered, 2)
+
+	merged := MergeMetricLists(m, filtered)
+	expectListCount(t, merged, 5)
+}
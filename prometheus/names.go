@@ -0,0 +1,37 @@
+package prometheus
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+)
+
+// IsValidLabelName checks if the label name is valid (legacy Prometheus naming, without UTF-8 names)
+func IsValidLabelName(name string) bool {
+	return labelNameRegexp.MatchString(name) && !strings.HasPrefix(name, "__")
+}
+
+// IsValidMetricName checks if the metric name is valid (legacy Prometheus naming, without UTF-8 names)
+func IsValidMetricName(name string) bool {
+	return metricNameRegexp.MatchString(name)
+}
+
+// LabelSetKey builds an unique key for a label set (independent of the label order)
+func LabelSetKey(labels prometheus.Labels) string {
+	var key strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		key.WriteString(name)
+		key.WriteByte(0xff)
+		key.WriteString(labels[name])
+		key.WriteByte(0xff)
+	}
+	return key.String()
+}
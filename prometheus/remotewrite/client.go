@@ -0,0 +1,163 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultMaxBatchSize = 1024 * 1024
+	DefaultRetries      = 3
+	DefaultMinBackoff   = 1 * time.Second
+	DefaultMaxBackoff   = 30 * time.Second
+
+	remoteWriteVersion = "0.1.0"
+	userAgent          = "go-common/remotewrite"
+)
+
+type (
+	// Client sends metric lists to a Prometheus remote write endpoint
+	Client struct {
+		url        string
+		httpClient *http.Client
+		auth       func(req *http.Request)
+		headers    http.Header
+		userAgent  string
+
+		maxBatchSize int
+
+		retry struct {
+			count      int
+			minBackoff time.Duration
+			maxBackoff time.Duration
+		}
+	}
+
+	// WriteError is returned if the remote write endpoint rejected the request
+	WriteError struct {
+		StatusCode int
+		Message    string
+	}
+)
+
+// NewClient creates a new remote write client for the url
+func NewClient(url string, opts ...ClientOptionFunc) *Client {
+	client := &Client{
+		url:          url,
+		httpClient:   http.DefaultClient,
+		headers:      http.Header{},
+		userAgent:    userAgent,
+		maxBatchSize: DefaultMaxBatchSize,
+	}
+	client.retry.count = DefaultRetries
+	client.retry.minBackoff = DefaultMinBackoff
+	client.retry.maxBackoff = DefaultMaxBackoff
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf(`remote write failed with status code %v: %v`, e.StatusCode, e.Message)
+}
+
+// isRetryable returns true for server errors and throttling
+func (e *WriteError) isRetryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// Write encodes the metric lists and sends them in batches to the remote write endpoint
+//
+//	rows without timestamp are written with the current time
+func (c *Client) Write(ctx context.Context, lists ...MetricList) error {
+	for _, batch := range Encode(time.Now(), c.maxBatchSize, lists...) {
+		if err := c.send(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// send sends the batch and retries on network errors, server errors and throttling
+func (c *Client) send(ctx context.Context, batch []byte) error {
+	backoff := c.retry.minBackoff
+	for try := 0; ; try++ {
+		retryAfter, err := c.sendRequest(ctx, batch)
+		if err == nil {
+			return nil
+		}
+
+		var writeErr *WriteError
+		if errors.As(err, &writeErr) && !writeErr.isRetryable() {
+			return err
+		}
+
+		if try >= c.retry.count {
+			return fmt.Errorf(`remote write failed after %v retries: %w`, try, err)
+		}
+
+		wait := backoff
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > c.retry.maxBackoff {
+			backoff = c.retry.maxBackoff
+		}
+	}
+}
+
+// sendRequest sends the batch, returns the Retry-After duration of throttled requests
+func (c *Client) sendRequest(ctx context.Context, batch []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(batch))
+	if err != nil {
+		return 0, err
+	}
+
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+	if c.auth != nil {
+		c.auth(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return retryAfter, &WriteError{StatusCode: resp.StatusCode, Message: string(bytes.TrimSpace(message))}
+}
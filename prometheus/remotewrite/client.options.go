@@ -0,0 +1,65 @@
+package remotewrite
+
+import (
+	"net/http"
+	"time"
+)
+
+type ClientOptionFunc func(*Client)
+
+// WithHTTPClient sets the HTTP client
+func WithHTTPClient(httpClient *http.Client) ClientOptionFunc {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithBasicAuth sets username and password for basic authentication
+func WithBasicAuth(username, password string) ClientOptionFunc {
+	return func(client *Client) {
+		client.auth = func(req *http.Request) {
+			req.SetBasicAuth(username, password)
+		}
+	}
+}
+
+// WithBearerToken sets the token for bearer authentication
+func WithBearerToken(token string) ClientOptionFunc {
+	return func(client *Client) {
+		client.auth = func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+// WithHeader sets an additional HTTP header for all requests
+func WithHeader(name, value string) ClientOptionFunc {
+	return func(client *Client) {
+		client.headers.Set(name, value)
+	}
+}
+
+// WithUserAgent sets the HTTP user agent
+func WithUserAgent(userAgent string) ClientOptionFunc {
+	return func(client *Client) {
+		client.userAgent = userAgent
+	}
+}
+
+// WithMaxBatchSize sets the maximum size of the (uncompressed) write request in bytes
+func WithMaxBatchSize(size int) ClientOptionFunc {
+	return func(client *Client) {
+		client.maxBatchSize = size
+	}
+}
+
+// WithRetry sets the number of retries and the backoff for failed requests (5xx and 429)
+//
+//	backoff is doubled after each retry up to maxBackoff
+func WithRetry(retries int, minBackoff, maxBackoff time.Duration) ClientOptionFunc {
+	return func(client *Client) {
+		client.retry.count = retries
+		client.retry.minBackoff = minBackoff
+		client.retry.maxBackoff = maxBackoff
+	}
+}
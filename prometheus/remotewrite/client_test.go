@@ -0,0 +1,270 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+type (
+	testSample struct {
+		labels    map[string]string
+		value     float64
+		timestamp int64
+	}
+
+	testReceiver struct {
+		mux       sync.Mutex
+		requests  int
+		samples   []testSample
+		responses []int
+		header    http.Header
+	}
+)
+
+func (r *testReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.requests++
+	r.header = req.Header.Clone()
+
+	if len(r.responses) > 0 {
+		status := r.responses[0]
+		r.responses = r.responses[1:]
+		if status != http.StatusNoContent {
+			w.WriteHeader(status)
+			return
+		}
+	}
+
+	compressed, _ := io.ReadAll(req.Body)
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	samples, err := decodeWriteRequest(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.samples = append(r.samples, samples...)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeWriteRequest decodes the WriteRequest protobuf message
+func decodeWriteRequest(data []byte) (samples []testSample, err error) {
+	err = decodeProtoMessage(data, func(num protowire.Number, value []byte) error {
+		if num != writeRequestFieldTimeseries {
+			return nil
+		}
+
+		sample := testSample{labels: map[string]string{}}
+		return decodeProtoMessage(value, func(num protowire.Number, value []byte) error {
+			switch num {
+			case timeSeriesFieldLabels:
+				var name, labelValue string
+				err := decodeProtoMessage(value, func(num protowire.Number, value []byte) error {
+					if num == labelFieldName {
+						name = string(value)
+					} else {
+						labelValue = string(value)
+					}
+					return nil
+				})
+				sample.labels[name] = labelValue
+				return err
+			case timeSeriesFieldSamples:
+				for len(value) > 0 {
+					num, typ, n := protowire.ConsumeTag(value)
+					value = value[n:]
+					switch {
+					case num == sampleFieldValue && typ == protowire.Fixed64Type:
+						v, n := protowire.ConsumeFixed64(value)
+						sample.value = math.Float64frombits(v)
+						value = value[n:]
+					case num == sampleFieldTimestamp && typ == protowire.VarintType:
+						v, n := protowire.ConsumeVarint(value)
+						sample.timestamp = int64(v)
+						value = value[n:]
+					default:
+						return fmt.Errorf("unexpected sample field %v", num)
+					}
+				}
+				samples = append(samples, sample)
+			}
+			return nil
+		})
+	})
+	return
+}
+
+// decodeProtoMessage calls the callback for every length delimited field
+func decodeProtoMessage(data []byte, callback func(num protowire.Number, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 || typ != protowire.BytesType {
+			return fmt.Errorf("unexpected field %v", num)
+		}
+		data = data[n:]
+
+		value, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if err := callback(num, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTestMetricList(rows int) *prometheusCommon.MetricList {
+	list := prometheusCommon.NewMetricsList()
+	for i := 0; i < rows; i++ {
+		list.Add(prometheus.Labels{"resourceID": fmt.Sprintf("/resource/%v", i)}, float64(i))
+	}
+	return list
+}
+
+func Test_RemoteWrite(t *testing.T) {
+	receiver := &testReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	timestamp := time.UnixMilli(1700000000000)
+	info := prometheusCommon.NewMetricsList()
+	info.AddWithTimestamp(prometheus.Labels{"resourceID": "/foo", "location": "westeurope"}, 1, timestamp)
+
+	client := NewClient(server.URL, WithHTTPClient(server.Client()), WithBearerToken("secret"))
+	err := client.Write(
+		context.Background(),
+		MetricList{Name: "azurerm_resource_info", List: info},
+		MetricList{Name: "azurerm_resource_count", List: newTestMetricList(2)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if receiver.header.Get("Authorization") != "Bearer secret" || receiver.header.Get("Content-Encoding") != "snappy" {
+		t.Errorf("unexpected request headers: %v", receiver.header)
+	}
+
+	if len(receiver.samples) != 3 {
+		t.Fatalf("expected 3 samples, got %v", receiver.samples)
+	}
+
+	sample := receiver.samples[0]
+	if sample.labels[MetricNameLabel] != "azurerm_resource_info" || sample.labels["location"] != "westeurope" || sample.value != 1 || sample.timestamp != 1700000000000 {
+		t.Errorf("unexpected sample: %v", sample)
+	}
+
+	if sample := receiver.samples[2]; sample.labels[MetricNameLabel] != "azurerm_resource_count" || sample.value != 1 || sample.timestamp == 0 {
+		t.Errorf("unexpected sample: %v", sample)
+	}
+}
+
+func Test_RemoteWriteEmptyLabels(t *testing.T) {
+	receiver := &testReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	list := prometheusCommon.NewMetricsList()
+	list.Add(prometheus.Labels{"resourceID": "/foo", "location": ""}, 1)
+
+	client := NewClient(server.URL, WithHTTPClient(server.Client()))
+	if err := client.Write(context.Background(), MetricList{Name: "azurerm_resource_info", List: list}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(receiver.samples) != 1 {
+		t.Fatalf("expected 1 sample, got %v", receiver.samples)
+	}
+
+	if labels := receiver.samples[0].labels; len(labels) != 2 || labels["resourceID"] != "/foo" {
+		t.Errorf("expected empty label to be skipped, got %v", labels)
+	}
+
+	if _, exists := receiver.samples[0].labels["location"]; exists {
+		t.Error("expected empty label to be skipped")
+	}
+}
+
+func Test_RemoteWriteBatches(t *testing.T) {
+	receiver := &testReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	client := NewClient(server.URL, WithHTTPClient(server.Client()), WithBasicAuth("user", "pass"), WithMaxBatchSize(1024))
+	if err := client.Write(context.Background(), MetricList{Name: "test_metric", List: newTestMetricList(100)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if receiver.requests < 2 {
+		t.Errorf("expected multiple batches, got %v requests", receiver.requests)
+	}
+
+	if len(receiver.samples) != 100 {
+		t.Errorf("expected 100 samples, got %v", len(receiver.samples))
+	}
+
+	if username, password, ok := (&http.Request{Header: receiver.header}).BasicAuth(); !ok || username != "user" || password != "pass" {
+		t.Error("expected basic auth")
+	}
+
+	for _, batch := range Encode(time.Now(), 1024, MetricList{Name: "test_metric", List: newTestMetricList(100)}) {
+		if data, err := snappy.Decode(nil, batch); err != nil || len(data) > 1024 {
+			t.Errorf("expected batch smaller than 1024 bytes, got %v (%v)", len(data), err)
+		}
+	}
+}
+
+func Test_RemoteWriteRetry(t *testing.T) {
+	receiver := &testReceiver{responses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusNoContent}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	client := NewClient(server.URL, WithHTTPClient(server.Client()), WithRetry(3, time.Millisecond, 5*time.Millisecond))
+	if err := client.Write(context.Background(), MetricList{Name: "test_metric", List: newTestMetricList(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if receiver.requests != 3 || len(receiver.samples) != 1 {
+		t.Errorf("expected 3 requests and 1 sample, got %v requests and %v samples", receiver.requests, len(receiver.samples))
+	}
+}
+
+func Test_RemoteWriteNoRetry(t *testing.T) {
+	receiver := &testReceiver{responses: []int{http.StatusBadRequest}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	client := NewClient(server.URL, WithHTTPClient(server.Client()), WithRetry(3, time.Millisecond, 5*time.Millisecond))
+	err := client.Write(context.Background(), MetricList{Name: "test_metric", List: newTestMetricList(1)})
+	var writeErr *WriteError
+	if !errors.As(err, &writeErr) || writeErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected write error with status code 400, got %v", err)
+	}
+
+	if receiver.requests != 1 {
+		t.Errorf("expected no retry for client errors, got %v requests", receiver.requests)
+	}
+}
@@ -0,0 +1,105 @@
+package remotewrite
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	prometheusCommon "github.com/webdevops/go-common/prometheus"
+)
+
+const (
+	MetricNameLabel = "__name__"
+
+	// protobuf field numbers of prometheus.WriteRequest (remote write 1.0)
+	writeRequestFieldTimeseries = 1
+	timeSeriesFieldLabels       = 1
+	timeSeriesFieldSamples      = 2
+	labelFieldName              = 1
+	labelFieldValue             = 2
+	sampleFieldValue            = 1
+	sampleFieldTimestamp        = 2
+)
+
+type (
+	// MetricList is a metric list with the metric name which is used as __name__ label
+	MetricList struct {
+		Name string
+		List *prometheusCommon.MetricList
+	}
+)
+
+// Encode encodes the metric lists into snappy compressed remote write requests
+//
+//	the write requests are split into batches with an uncompressed size of maxBatchSize (0 = no limit),
+//	rows without timestamp are written with the passed timestamp
+func Encode(timestamp time.Time, maxBatchSize int, lists ...MetricList) [][]byte {
+	batches := [][]byte{}
+
+	batch := []byte{}
+	for _, list := range lists {
+		for _, row := range list.List.GetList() {
+			rowTimestamp := timestamp
+			if row.Timestamp != nil {
+				rowTimestamp = *row.Timestamp
+			}
+
+			series := encodeTimeSeries(list.Name, row, rowTimestamp)
+			size := protowire.SizeTag(writeRequestFieldTimeseries) + protowire.SizeBytes(len(series))
+
+			if maxBatchSize > 0 && len(batch) > 0 && len(batch)+size > maxBatchSize {
+				batches = append(batches, snappy.Encode(nil, batch))
+				batch = []byte{}
+			}
+
+			batch = protowire.AppendTag(batch, writeRequestFieldTimeseries, protowire.BytesType)
+			batch = protowire.AppendBytes(batch, series)
+		}
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, snappy.Encode(nil, batch))
+	}
+
+	return batches
+}
+
+// encodeTimeSeries encodes the row as TimeSeries with one sample, labels are sorted by name
+// labels with empty values are skipped as Prometheus treats them as not set
+func encodeTimeSeries(name string, row prometheusCommon.MetricRow, timestamp time.Time) []byte {
+	labels := maps.Clone(row.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	maps.DeleteFunc(labels, func(labelName, labelValue string) bool {
+		return labelValue == ""
+	})
+	labels[MetricNameLabel] = name
+
+	series := []byte{}
+	for _, labelName := range slices.Sorted(maps.Keys(labels)) {
+		label := []byte{}
+		label = protowire.AppendTag(label, labelFieldName, protowire.BytesType)
+		label = protowire.AppendString(label, labelName)
+		label = protowire.AppendTag(label, labelFieldValue, protowire.BytesType)
+		label = protowire.AppendString(label, labels[labelName])
+
+		series = protowire.AppendTag(series, timeSeriesFieldLabels, protowire.BytesType)
+		series = protowire.AppendBytes(series, label)
+	}
+
+	sample := []byte{}
+	sample = protowire.AppendTag(sample, sampleFieldValue, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(row.Value))
+	sample = protowire.AppendTag(sample, sampleFieldTimestamp, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestamp.UnixMilli()))
+
+	series = protowire.AppendTag(series, timeSeriesFieldSamples, protowire.BytesType)
+	series = protowire.AppendBytes(series, sample)
+
+	return series
+}
@@ -0,0 +1,401 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	TypedMetricListTagLabel = "label"
+	TypedMetricListTagValue = "value"
+)
+
+type (
+	// TypedMetricList is a metric list with a fixed label schema derived from the struct T
+	//
+	//	labels are defined by `label:"name"` tags, the value is taken from the field tagged with `value:""`
+	//	or (if no field is tagged) from the field named "Value"
+	//
+	//	type ResourceRow struct {
+	//	  ResourceID string  `label:"resourceID"`
+	//	  Location   string  `label:"location"`
+	//	  Value      float64
+	//	}
+	TypedMetricList[T any] struct {
+		List []T
+		mux  *sync.Mutex
+
+		schema *typedMetricSchema
+
+		metricsCache *cache.Cache
+	}
+
+	typedMetricSchema struct {
+		labelNames  []string
+		labelFields []int
+		valueField  int
+	}
+)
+
+var (
+	typedMetricSchemaCache sync.Map
+)
+
+// NewTypedMetricList creates a new typed metric list, panics if T is not a valid row struct
+func NewTypedMetricList[T any]() *TypedMetricList[T] {
+	m := TypedMetricList[T]{}
+	m.Init()
+	return &m
+}
+
+func (m *TypedMetricList[T]) Init() {
+	m.mux = &sync.Mutex{}
+
+	schema, err := typedMetricSchemaFor(reflect.TypeFor[T]())
+	if err != nil {
+		panic(err)
+	}
+	m.schema = schema
+
+	if m.List == nil {
+		m.List = []T{}
+	}
+}
+
+func (m *TypedMetricList[T]) SetCache(instance *cache.Cache) {
+	m.metricsCache = instance
+}
+
+func (m *TypedMetricList[T]) LoadFromCache(key string) bool {
+	m.Reset()
+
+	if m.metricsCache != nil {
+		m.mux.Lock()
+		defer m.mux.Unlock()
+
+		if val, fetched := m.metricsCache.Get(key); fetched {
+			// loaded from cache
+			m.List = val.([]T)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *TypedMetricList[T]) StoreToCache(key string, duration time.Duration) error {
+	if m.metricsCache != nil {
+		return m.metricsCache.Add(key, m.GetList(), duration)
+	}
+	return nil
+}
+
+// LabelNames returns the label names of the schema, vecs must be created with these label names (in this order)
+func (m *TypedMetricList[T]) LabelNames() []string {
+	return append([]string{}, m.schema.labelNames...)
+}
+
+func (m *TypedMetricList[T]) Add(rows ...T) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.List = append(m.List, rows...)
+}
+
+func (m *TypedMetricList[T]) Reset() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.List = []T{}
+}
+
+func (m *TypedMetricList[T]) GetList() []T {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.List == nil {
+		m.List = []T{}
+	}
+
+	return m.List
+}
+
+// GetMetricRows converts the list into generic metric rows
+func (m *TypedMetricList[T]) GetMetricRows() []MetricRow {
+	list := m.GetList()
+
+	ret := make([]MetricRow, 0, len(list))
+	for i := range list {
+		row := reflect.ValueOf(&list[i]).Elem()
+
+		labels := make(prometheus.Labels, len(m.schema.labelNames))
+		for n, labelName := range m.schema.labelNames {
+			labels[labelName] = typedMetricFieldToString(row.Field(m.schema.labelFields[n]))
+		}
+
+		ret = append(ret, MetricRow{Labels: labels, Value: typedMetricFieldToFloat64(row.Field(m.schema.valueField))})
+	}
+
+	return ret
+}
+
+// rows iterates over all rows and passes label values and value, the label value slice is reused between rows
+func (m *TypedMetricList[T]) rows(callback func(labelValues []string, value float64)) {
+	labelValues := make([]string, len(m.schema.labelFields))
+
+	list := m.GetList()
+	for i := range list {
+		row := reflect.ValueOf(&list[i]).Elem()
+		for n, field := range m.schema.labelFields {
+			labelValues[n] = typedMetricFieldToString(row.Field(field))
+		}
+		callback(labelValues, typedMetricFieldToFloat64(row.Field(m.schema.valueField)))
+	}
+}
+
+func (m *TypedMetricList[T]) GaugeSet(gauge *prometheus.GaugeVec) {
+	m.rows(func(labelValues []string, value float64) {
+		gauge.WithLabelValues(labelValues...).Set(value)
+	})
+}
+
+func (m *TypedMetricList[T]) GaugeSetInc(gauge *prometheus.GaugeVec) {
+	m.rows(func(labelValues []string, value float64) {
+		if metricGauge, err := gauge.GetMetricWithLabelValues(labelValues...); err == nil {
+			metricGauge.Add(value)
+		} else {
+			panic(err)
+		}
+	})
+}
+
+func (m *TypedMetricList[T]) SummarySet(summary *prometheus.SummaryVec) {
+	m.rows(func(labelValues []string, value float64) {
+		summary.WithLabelValues(labelValues...).Observe(value)
+	})
+}
+
+func (m *TypedMetricList[T]) HistogramSet(histogram *prometheus.HistogramVec) {
+	m.rows(func(labelValues []string, value float64) {
+		histogram.WithLabelValues(labelValues...).Observe(value)
+	})
+}
+
+func (m *TypedMetricList[T]) CounterAdd(counter *prometheus.CounterVec) {
+	m.rows(func(labelValues []string, value float64) {
+		counter.WithLabelValues(labelValues...).Add(value)
+	})
+}
+
+// MarshalJSON serializes the list in the same format as MetricList
+func (m *TypedMetricList[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		List []MetricRow `json:"list"`
+	}{
+		List: m.GetMetricRows(),
+	})
+}
+
+// UnmarshalJSON deserializes the list from the MetricList format
+func (m *TypedMetricList[T]) UnmarshalJSON(data []byte) error {
+	if m.mux == nil {
+		m.Init()
+	}
+
+	parsed := struct {
+		List []MetricRow `json:"list"`
+	}{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+
+	list := make([]T, len(parsed.List))
+	for i, metricRow := range parsed.List {
+		row := reflect.ValueOf(&list[i]).Elem()
+		for n, labelName := range m.schema.labelNames {
+			if err := typedMetricFieldFromString(row.Field(m.schema.labelFields[n]), metricRow.Labels[labelName]); err != nil {
+				return fmt.Errorf(`unable to restore label "%v": %w`, labelName, err)
+			}
+		}
+		typedMetricFieldFromFloat64(row.Field(m.schema.valueField), metricRow.Value)
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.List = list
+
+	return nil
+}
+
+// typedMetricSchemaFor builds (and caches) the label schema of a row struct
+func typedMetricSchemaFor(t reflect.Type) (*typedMetricSchema, error) {
+	if val, exists := typedMetricSchemaCache.Load(t); exists {
+		return val.(*typedMetricSchema), nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf(`typed metric row must be a struct, got %v`, t)
+	}
+
+	schema := &typedMetricSchema{valueField: -1}
+	valueFieldByName := -1
+	labelNameList := map[string]string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if labelName, exists := field.Tag.Lookup(TypedMetricListTagLabel); exists && labelName != "-" {
+			if !field.IsExported() {
+				return nil, fmt.Errorf(`typed metric row %v: label field "%v" must be exported`, t, field.Name)
+			}
+
+			if !typedMetricIsSupportedKind(field.Type.Kind()) {
+				return nil, fmt.Errorf(`typed metric row %v: label field "%v" has unsupported type %v`, t, field.Name, field.Type)
+			}
+
+			if !IsValidLabelName(labelName) {
+				return nil, fmt.Errorf(`typed metric row %v: label field "%v" has invalid label name "%v"`, t, field.Name, labelName)
+			}
+
+			if otherField, exists := labelNameList[labelName]; exists {
+				return nil, fmt.Errorf(`typed metric row %v: label "%v" is used by field "%v" and "%v"`, t, labelName, otherField, field.Name)
+			}
+			labelNameList[labelName] = field.Name
+
+			schema.labelNames = append(schema.labelNames, labelName)
+			schema.labelFields = append(schema.labelFields, i)
+			continue
+		}
+
+		if _, exists := field.Tag.Lookup(TypedMetricListTagValue); exists {
+			if schema.valueField >= 0 {
+				return nil, fmt.Errorf(`typed metric row %v: multiple value fields found`, t)
+			}
+			schema.valueField = i
+		} else if field.Name == "Value" {
+			valueFieldByName = i
+		}
+	}
+
+	if schema.valueField < 0 {
+		schema.valueField = valueFieldByName
+	}
+
+	if schema.valueField < 0 {
+		return nil, fmt.Errorf(`typed metric row %v: no value field found`, t)
+	}
+
+	if valueField := t.Field(schema.valueField); !valueField.IsExported() || !typedMetricIsSupportedKind(valueField.Type.Kind()) || valueField.Type.Kind() == reflect.String {
+		return nil, fmt.Errorf(`typed metric row %v: value field "%v" must be an exported numeric or bool field`, t, valueField.Name)
+	}
+
+	val, _ := typedMetricSchemaCache.LoadOrStore(t, schema)
+	return val.(*typedMetricSchema), nil
+}
+
+func typedMetricIsSupportedKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func typedMetricFieldToString(field reflect.Value) string {
+	switch field.Kind() {
+	case reflect.String:
+		return field.String()
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'g', -1, 64)
+	}
+	return ""
+}
+
+func typedMetricFieldFromString(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		if value == "" {
+			return nil
+		}
+		val, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value == "" {
+			return nil
+		}
+		val, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value == "" {
+			return nil
+		}
+		val, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(val)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			return nil
+		}
+		val, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(val)
+	}
+	return nil
+}
+
+func typedMetricFieldToFloat64(field reflect.Value) float64 {
+	switch field.Kind() {
+	case reflect.Bool:
+		if field.Bool() {
+			return 1
+		}
+		return 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(field.Uint())
+	case reflect.Float32, reflect.Float64:
+		return field.Float()
+	}
+	return 0
+}
+
+func typedMetricFieldFromFloat64(field reflect.Value, value float64) {
+	switch field.Kind() {
+	case reflect.Bool:
+		field.SetBool(value != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(int64(value))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(uint64(value))
+	case reflect.Float32, reflect.Float64:
+		field.SetFloat(value)
+	}
+}
@@ -0,0 +1,96 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type (
+	testTypedMetricRow struct {
+		ResourceID string `label:"resourceID"`
+		Location   string `label:"location"`
+		Enabled    bool   `label:"enabled"`
+		Value      float64
+	}
+
+	testTypedMetricRowTaggedValue struct {
+		Name  string `label:"name"`
+		Count int64  `value:""`
+	}
+)
+
+func Test_TypedMetricList(t *testing.T) {
+	m := NewTypedMetricList[testTypedMetricRow]()
+
+	labelNames := m.LabelNames()
+	if len(labelNames) != 3 || labelNames[0] != "resourceID" || labelNames[1] != "location" || labelNames[2] != "enabled" {
+		t.Fatalf("unexpected label names: %v", labelNames)
+	}
+
+	m.Add(
+		testTypedMetricRow{ResourceID: "/foo", Location: "westeurope", Enabled: true, Value: 12},
+		testTypedMetricRow{ResourceID: "/bar", Location: "northeurope", Value: 3},
+	)
+
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_typed"}, m.LabelNames())
+	m.GaugeSet(gauge)
+
+	if val := testutil.ToFloat64(gauge.WithLabelValues("/foo", "westeurope", "true")); val != 12 {
+		t.Errorf("expected value 12, got %v", val)
+	}
+
+	if val := testutil.ToFloat64(gauge.WithLabelValues("/bar", "northeurope", "false")); val != 3 {
+		t.Errorf("expected value 3, got %v", val)
+	}
+
+	rows := m.GetMetricRows()
+	expectMetricRowLabel(t, rows[0], "resourceID", "/foo")
+	expectMetricRowLabel(t, rows[0], "enabled", "true")
+	expectMetricRowValue(t, rows[0], 12)
+}
+
+func Test_TypedMetricListJson(t *testing.T) {
+	m := NewTypedMetricList[testTypedMetricRowTaggedValue]()
+	m.Add(testTypedMetricRowTaggedValue{Name: "foo", Count: 42})
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// must be compatible with MetricList cache format
+	metricList := NewMetricsList()
+	if err := json.Unmarshal(data, metricList); err != nil {
+		t.Fatal(err)
+	}
+	expectListCount(t, metricList, 1)
+	expectMetricRowLabel(t, metricList.GetList()[0], "name", "foo")
+	expectMetricRowValue(t, metricList.GetList()[0], 42)
+
+	restored := NewTypedMetricList[testTypedMetricRowTaggedValue]()
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+
+	if list := restored.GetList(); len(list) != 1 || list[0].Name != "foo" || list[0].Count != 42 {
+		t.Errorf("unexpected restored list: %v", list)
+	}
+}
+
+func Test_TypedMetricListInvalidSchema(t *testing.T) {
+	type invalidRow struct {
+		Name  string `label:"invalid-name"`
+		Value float64
+	}
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("expected panic for invalid label name")
+		}
+	}()
+
+	NewTypedMetricList[invalidRow]()
+}